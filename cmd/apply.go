@@ -1,29 +1,54 @@
 package cmd
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/fatih/color"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/avinor/tau/internal/templates"
 	"github.com/avinor/tau/pkg/config/loader"
 	"github.com/avinor/tau/pkg/helpers/paths"
 	"github.com/avinor/tau/pkg/helpers/ui"
+	"github.com/avinor/tau/pkg/report"
 	"github.com/avinor/tau/pkg/shell"
 	"github.com/avinor/tau/pkg/shell/processors"
+	"github.com/avinor/tau/pkg/terraform/def"
 )
 
 type applyCmd struct {
 	meta
 
-	autoApprove bool
-	deletePlan  bool
+	autoApprove   bool
+	deletePlan    bool
+	yesToDestroys bool
+	replan        bool
+
+	summaries map[*loader.ParsedFile]*def.PlanSummary
+	prepared  map[*loader.ParsedFile]bool
 }
 
 var (
+	// applyNotApproved is returned if user does not approve plans
+	applyNotApproved = errors.Errorf("apply cancelled, plans were not approved")
+
+	// destroyNotApproved is returned if plans destroy resources and yes-to-destroys is not set
+	destroyNotApproved = errors.Errorf("plans destroy resources, use --yes-to-destroys to approve destroying resources")
+
 	// applyLong is long description of apply command
 	applyLong = templates.LongDesc(`Apply an execution plan where its possible. It will
 		loop through all plans generated from plan command and execute them. It will only
 		execute for those modules that successfully generated a plan.
+
+		Unless auto approve is set it will show a summary of all plans and ask for
+		a single approval before applying them. If any of the plans destroy resources
+		--yes-to-destroys has to be set as well.
+
+		Before applying a plan it verifies that configuration, input variables, module
+		and dependency values have not changed since plan was created. If they have
+		changed it will refuse to apply the plan, unless --replan is set.
 		`)
 
 	// applyExample is examples for apply command
@@ -36,6 +61,9 @@ var (
 
 		# Apply a single module and auto approve
 		tau apply -f module.hcl --no-input
+
+		# Apply plans that destroy resources
+		tau apply --yes-to-destroys
 	`)
 )
 
@@ -53,19 +81,30 @@ func newApplyCmd() *cobra.Command {
 		SilenceErrors:         true,
 		Args:                  cobra.MaximumNArgs(0),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := ac.meta.init(args); err != nil {
+			if err := ac.meta.init(cmd.Context(), args); err != nil {
+				return err
+			}
+
+			unlock, err := ac.lock(cmd.Name())
+			if err != nil {
 				return err
 			}
+			defer unlock()
 
-			return ac.run(args)
+			return ac.writeReport(ac.run(args))
 		},
 	}
 
 	f := applyCmd.Flags()
 	f.BoolVar(&ac.autoApprove, "auto-approve", false, "auto approve deployment")
 	f.BoolVar(&ac.deletePlan, "delete-plan", true, "delete terraform plan on success")
+	f.BoolVar(&ac.yesToDestroys, "yes-to-destroys", false, "approve plans that destroy resources")
+	f.BoolVar(&ac.replan, "replan", false, "create a new plan if plan is stale")
 
 	ac.addMetaFlags(applyCmd)
+	ac.addRefreshModulesFlag(applyCmd)
+	ac.addWalkFlags(applyCmd)
+	ac.addRefreshInputsFlag(applyCmd)
 
 	return applyCmd
 }
@@ -84,6 +123,9 @@ func (ac *applyCmd) run(args []string) error {
 		}
 	}
 
+	ac.summaries = map[*loader.ParsedFile]*def.PlanSummary{}
+	ac.prepared = map[*loader.ParsedFile]bool{}
+
 	// Check if any plans exist, if not then run plan first
 	noPlansExists := true
 	for _, file := range files {
@@ -95,9 +137,13 @@ func (ac *applyCmd) run(args []string) error {
 
 	if !noPlansExists {
 		ui.Header("Found tau.plan files, only applying valid plans...")
+
+		if err := ac.approvePlans(files); err != nil {
+			return err
+		}
 	}
 
-	if err := files.Walk(func(file *loader.ParsedFile) error {
+	if err := ac.walk(files, "apply", false, func(file *loader.ParsedFile) error {
 		return ac.runFile(file, !noPlansExists)
 	}); err != nil {
 		return err
@@ -108,10 +154,126 @@ func (ac *applyCmd) run(args []string) error {
 	return nil
 }
 
-func (ac *applyCmd) runFile(file *loader.ParsedFile, onlyPlans bool) error {
+// approvePlans reads the summary of changes in all plans and asks user for a single approval
+// to apply all of them, see confirmPlans. A deployment where plan cannot be read fails, and
+// with keep-going it is not applied while the other plans can be approved.
+func (ac *applyCmd) approvePlans(files loader.ParsedFileCollection) error {
+	summaries := map[*loader.ParsedFile]*def.PlanSummary{}
+
+	for _, file := range files {
+		if !paths.IsFile(file.PlanFile()) {
+			continue
+		}
+
+		err := ac.runBeforeHooks(file, "apply", func(file *loader.ParsedFile) error {
+			summary, err := ac.readPlanSummary(file)
+			summaries[file] = summary
+			return err
+		})
+
+		if err != nil {
+			if !ac.keepGoing {
+				return err
+			}
+
+			ui.Error("Failed reading plan for %s: %s", file.Name, err)
+			delete(summaries, file)
+			ac.failed[file] = err
+		}
+	}
+
+	if err := ac.confirmPlans(files, summaries); err != nil {
+		for file := range summaries {
+			ac.setStatus(file, report.Skipped, "%s", err)
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// confirmPlans shows a summary of changes in plans and asks user for a single approval to
+// apply all of them. Plans are applied without asking again once approved. With auto approve
+// it does not ask, but plans destroying resources still require yes-to-destroys.
+func (ac *applyCmd) confirmPlans(files loader.ParsedFileCollection, summaries map[*loader.ParsedFile]*def.PlanSummary) error {
+	total := &def.PlanSummary{}
+
+	for file, summary := range summaries {
+		ac.summaries[file] = summary
+		total.Add += summary.Add
+		total.Change += summary.Change
+		total.Destroy += summary.Destroy
+	}
+
+	ui.Header("Plan summary:")
+
+	for _, file := range files {
+		if err, ok := ac.failed[file]; ok {
+			ui.Info("- %s: %s: %s", file.Name, color.RedString("failed reading plan"), err)
+			continue
+		}
+
+		summary, ok := summaries[file]
+		if !ok {
+			ui.Info("- %s: no plan", file.Name)
+			continue
+		}
+
+		ui.Info("- %s: %s", file.Name, formatPlanSummary(summary))
+	}
+
+	ui.NewLine()
+	ui.Info(color.New(color.Bold).Sprintf("Total: %s", formatPlanSummary(total)))
+	ui.NewLine()
+
+	if !total.HasChanges() {
+		return nil
+	}
+
+	if total.Destroy > 0 && !ac.yesToDestroys {
+		return destroyNotApproved
+	}
+
+	if ac.autoApprove {
+		return nil
+	}
+
+	answer, err := ui.Ask("Do you want to apply all plans? Only 'yes' will be accepted to approve:")
+	if err != nil {
+		return err
+	}
+
+	if answer != "yes" {
+		return applyNotApproved
+	}
+
+	return nil
+}
+
+// readPlanSummary runs prepare hooks, initializes module if required and reads the changes
+// in plan for file. Prepare hooks are not run again when applying plan.
+func (ac *applyCmd) readPlanSummary(file *loader.ParsedFile) (*def.PlanSummary, error) {
 	ui.Separator(file.Name)
 
-	// Running prepare hook
+	if err := ac.runPrepareHooks(file); err != nil {
+		return nil, err
+	}
+
+	if err := ac.autoInit(file); err != nil {
+		return nil, err
+	}
+
+	ui.Info("- Reading plan for %s", file.Name)
+
+	return ac.planSummary(file)
+}
+
+// runPrepareHooks runs prepare hooks for file, unless they already ran when reading plan
+func (ac *applyCmd) runPrepareHooks(file *loader.ParsedFile) error {
+	if ac.prepared[file] {
+		return nil
+	}
 
 	ui.Header("Executing prepare hooks...")
 
@@ -119,28 +281,129 @@ func (ac *applyCmd) runFile(file *loader.ParsedFile, onlyPlans bool) error {
 		return err
 	}
 
-	ac.autoInit(file)
+	ac.prepared[file] = true
 
-	// Resolving dependencies
+	return nil
+}
 
-	if !paths.IsFile(file.VariableFile()) {
-		success, err := ac.resolveDependencies(file)
-		if err != nil {
+// verifyPlan checks that config, inputs, module and dependency values have not changed since
+// plan was created. If plan is stale it returns an error, unless replan is set, then it creates
+// a new plan. New plan has to be approved again, see approvePlans.
+func (ac *applyCmd) verifyPlan(file *loader.ParsedFile) error {
+	planned, err := file.ReadFingerprint()
+	if err != nil {
+		return err
+	}
+
+	if planned == nil {
+		ui.Warn("No fingerprint found for plan, cannot verify that plan is up to date")
+		return nil
+	}
+
+	current, err := file.Fingerprint()
+	if err != nil {
+		return err
+	}
+
+	changes := planned.Diff(current)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	if !ac.replan {
+		return errors.Errorf("plan for %s is stale, %s changed since plan was created. Run plan again or use --replan", file.Name, strings.Join(changes, ", "))
+	}
+
+	ui.Warn("Plan is stale, %s changed since plan was created. Creating a new plan", strings.Join(changes, ", "))
+
+	if err := ac.createPlan(file, planned.Destroy); err != nil {
+		return err
+	}
+
+	summary, err := ac.planSummary(file)
+	if err != nil {
+		return err
+	}
+
+	return ac.confirmPlans(loader.ParsedFileCollection{file}, map[*loader.ParsedFile]*def.PlanSummary{file: summary})
+}
+
+// reportPlanSummary adds summary of plan to report, if writing a report. Reads the summary
+// from plan unless it was read when approving plans.
+func (ac *applyCmd) reportPlanSummary(file *loader.ParsedFile) error {
+	if ac.reportFile == "" {
+		return nil
+	}
+
+	summary, ok := ac.summaries[file]
+	if !ok {
+		var err error
+		if summary, err = ac.planSummary(file); err != nil {
 			return err
 		}
+	}
 
-		if !success {
-			return nil
-		}
+	ac.setPlanSummary(file, summary)
+
+	return nil
+}
+
+// formatPlanSummary returns summary in same format as terraform, destroys are highlighted
+func formatPlanSummary(summary *def.PlanSummary) string {
+	destroy := fmt.Sprintf("%v to destroy", summary.Destroy)
+	if summary.Destroy > 0 {
+		destroy = color.RedString(destroy)
 	}
 
+	return fmt.Sprintf("%v to add, %v to change, %s", summary.Add, summary.Change, destroy)
+}
+
+func (ac *applyCmd) runFile(file *loader.ParsedFile, onlyPlans bool) error {
+	ui.Separator(file.Name)
+
+	// Running prepare hook
+
+	if err := ac.runPrepareHooks(file); err != nil {
+		return err
+	}
+
+	ac.autoInit(file)
+
 	planFileExists := paths.IsFile(file.PlanFile())
 
 	if !planFileExists && onlyPlans {
 		ui.Warn("No plan exists")
+		ac.setStatus(file, report.NoPlan, "no plan exists")
+		return nil
+	}
+
+	// Resolving dependencies, always resolved for plans to verify plan is not stale
+
+	resolve := ac.ensureInputs
+	if planFileExists {
+		resolve = ac.resolveDependencies
+	}
+
+	success, err := resolve(file, "apply")
+	if err != nil {
+		return err
+	}
+
+	if !success {
+		ac.setStatus(file, report.Skipped, dependenciesNotResolved)
 		return nil
 	}
 
+	if planFileExists {
+		if err := ac.verifyPlan(file); err != nil {
+			return err
+		}
+
+		if err := ac.reportPlanSummary(file); err != nil {
+			return err
+		}
+	}
+
 	// Executing terraform command
 
 	ui.NewLine()
@@ -152,6 +415,7 @@ func (ac *applyCmd) runFile(file *loader.ParsedFile, onlyPlans bool) error {
 		Stdout:           shell.Processors(processors.NewUI(ui.Info)),
 		Stderr:           shell.Processors(processors.NewUI(ui.Error)),
 		Env:              file.Env,
+		Retry:            file.ApplyRetryPolicy(),
 	}
 
 	extraArgs := getExtraArgs(ac.Engine.Compatibility.GetInvalidArgs("apply")...)
@@ -165,12 +429,17 @@ func (ac *applyCmd) runFile(file *loader.ParsedFile, onlyPlans bool) error {
 		extraArgs = append(extraArgs, file.PlanFile())
 	}
 
-	if err := ac.Engine.Executor.Execute(options, "apply", extraArgs...); err != nil {
+	if err := ac.Engine.Executor.Execute(file.Context(), options, "apply", extraArgs...); err != nil {
 		return err
 	}
 
 	if ac.deletePlan {
 		paths.Remove(file.PlanFile())
+		paths.Remove(file.FingerprintFile())
+	}
+
+	if err := ac.readOutputs(file, "apply"); err != nil {
+		ui.Warn("Could not read outputs for hooks: %s", err)
 	}
 
 	// Executing finish hook
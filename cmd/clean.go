@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avinor/tau/internal/templates"
+	"github.com/avinor/tau/pkg/getter"
+	"github.com/avinor/tau/pkg/helpers/lock"
+	"github.com/avinor/tau/pkg/helpers/paths"
+	"github.com/avinor/tau/pkg/helpers/ui"
+	"github.com/avinor/tau/pkg/hooks"
+)
+
+type cleanCmd struct {
+	hooks   bool
+	modules bool
+	all     bool
+}
+
+var (
+	// cleanLong is long description of clean command
+	cleanLong = templates.LongDesc(`Remove temporary files created by tau. By default it
+		removes the temporary .tau directory with downloaded modules and plans. Use --hooks
+		to only invalidate cached output from hooks, --modules to only remove the module cache,
+		or --all to also remove entire cache directory.
+		`)
+
+	// cleanExample is examples for clean command
+	cleanExample = templates.Examples(`
+		# Remove temporary directory
+		tau clean
+
+		# Invalidate cached output from hooks
+		tau clean --hooks
+
+		# Remove cached modules, so they are downloaded again
+		tau clean --modules
+	`)
+)
+
+// newCleanCmd creates a new clean command
+func newCleanCmd() *cobra.Command {
+	cc := &cleanCmd{}
+
+	cleanCmd := &cobra.Command{
+		Use:                   "clean",
+		Short:                 "Remove temporary files and cache",
+		Long:                  cleanLong,
+		Example:               cleanExample,
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		SilenceErrors:         true,
+		Args:                  cobra.MaximumNArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cc.run(args)
+		},
+	}
+
+	f := cleanCmd.Flags()
+	f.BoolVar(&cc.hooks, "hooks", false, "only remove cached output from hooks")
+	f.BoolVar(&cc.modules, "modules", false, "only remove cached modules")
+	f.BoolVar(&cc.all, "all", false, "remove temporary directory and entire cache directory")
+
+	return cleanCmd
+}
+
+func (cc *cleanCmd) run(args []string) error {
+	if workingDir == "" {
+		workingDir = paths.WorkingDir
+	}
+
+	tauDir := paths.Join(workingDir, paths.TauPath)
+	cacheDir := paths.Join(workingDir, paths.CachePath)
+
+	if cc.hooks {
+		ui.Info("- Removing cached hook output")
+
+		return hooks.NewOutputCache(filepath.Join(cacheDir, hooks.CacheDirName)).Clear()
+	}
+
+	if cc.modules {
+		ui.Info("- Removing cached modules")
+
+		return getter.NewModuleCache(filepath.Join(cacheDir, getter.ModulesDirName), nil, false).Clear()
+	}
+
+	// Make sure no other tau process is using directory before removing it
+	unlock, err := acquireLock(filepath.Join(tauDir, lock.FileName), "clean", 0, filepath.Join(tauDir, "*"+lock.Extension))
+	if err != nil {
+		return err
+	}
+
+	// Module cache can be used by tau processes in other directories, so it is cleared with its own lock
+	if cc.all {
+		if err := getter.NewModuleCache(filepath.Join(cacheDir, getter.ModulesDirName), nil, false).Clear(); err != nil {
+			unlock()
+			return err
+		}
+	}
+
+	ui.Info("- Removing %s", tauDir)
+	unlock()
+	paths.Remove(tauDir)
+
+	if cc.all {
+		ui.Info("- Removing %s", cacheDir)
+		paths.Remove(cacheDir)
+	}
+
+	return nil
+}
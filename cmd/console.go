@@ -0,0 +1,278 @@
+package cmd
+
+import (
+	"io"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/gohcl"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/avinor/tau/internal/templates"
+	"github.com/avinor/tau/pkg/config/loader"
+	"github.com/avinor/tau/pkg/helpers/ui"
+)
+
+// consoleCmd evaluates expressions in context of a deployment, either interactively with
+// console command or a single expression with eval command
+type consoleCmd struct {
+	meta
+
+	// evalContext is context of deployment with dependencies resolved, set by prepare
+	evalContext *hcl.EvalContext
+}
+
+var (
+	// consoleRequiresSingleFile is returned if file argument references more than one deployment
+	consoleRequiresSingleFile = errors.Errorf("can only evaluate expressions for a single file, use -f to select deployment")
+
+	// consoleLong is long description of console command
+	consoleLong = templates.LongDesc(`Interactive console for evaluating expressions in
+		context of a deployment. It runs prepare hooks and resolves dependencies and data
+		sources, so expressions can reference the same values as the configuration file,
+		for instance dependency.vnet.outputs, data, source and module. Values of input
+		variables are available as inputs.
+
+		Dependencies and data sources are only resolved for values referenced in inputs.
+		Type exit, or press Ctrl-D, to leave the console.
+		`)
+
+	// consoleExample is examples for console command
+	consoleExample = templates.Examples(`
+		# Start console for a deployment
+		tau console -f module.hcl
+	`)
+
+	// evalLong is long description of eval command
+	evalLong = templates.LongDesc(`Evaluate an expression in context of a deployment and print
+		the result. It runs prepare hooks and resolves dependencies and data sources first,
+		same as console.
+		`)
+
+	// evalExample is examples for eval command
+	evalExample = templates.Examples(`
+		# Print the subnets read from vnet dependency
+		tau eval -f module.hcl 'dependency.vnet.outputs.subnets'
+
+		# Print value of an input variable
+		tau eval -f module.hcl 'inputs.name'
+	`)
+)
+
+// newConsoleCmd creates a new console command
+func newConsoleCmd() *cobra.Command {
+	cc := &consoleCmd{}
+
+	consoleCmd := &cobra.Command{
+		Use:                   "console -f SOURCE",
+		Short:                 "Interactive console for evaluating expressions",
+		Long:                  consoleLong,
+		Example:               consoleExample,
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		SilenceErrors:         true,
+		Args:                  cobra.MaximumNArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cc.prepare(cmd); err != nil {
+				return err
+			}
+
+			return cc.runConsole()
+		},
+	}
+
+	cc.addMetaFlags(consoleCmd)
+	consoleCmd.MarkFlagRequired("file")
+
+	return consoleCmd
+}
+
+// newEvalCmd creates a new eval command
+func newEvalCmd() *cobra.Command {
+	cc := &consoleCmd{}
+
+	evalCmd := &cobra.Command{
+		Use:                   "eval -f SOURCE EXPRESSION",
+		Short:                 "Evaluate an expression and print the result",
+		Long:                  evalLong,
+		Example:               evalExample,
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		SilenceErrors:         true,
+		Args:                  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cc.prepare(cmd); err != nil {
+				return err
+			}
+
+			result, err := cc.eval(args[0])
+			if err != nil {
+				return err
+			}
+
+			ui.Output("%s", result)
+
+			return nil
+		},
+	}
+
+	cc.addMetaFlags(evalCmd)
+	evalCmd.MarkFlagRequired("file")
+
+	return evalCmd
+}
+
+// prepare loads the deployment, runs prepare hooks and resolves dependencies. Lock is only
+// held while preparing, so an interactive console does not block other commands.
+func (cc *consoleCmd) prepare(cmd *cobra.Command) error {
+	if err := cc.meta.init(cmd.Context(), nil); err != nil {
+		return err
+	}
+
+	unlock, err := cc.lock(cmd.Name())
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	files, err := cc.load()
+	if err != nil {
+		return err
+	}
+
+	if len(files) != 1 {
+		return consoleRequiresSingleFile
+	}
+
+	return cc.runWithHooks(files[0], cmd.Name(), func(file *loader.ParsedFile) error {
+		ui.Separator(file.Name)
+
+		// Running prepare hook
+
+		ui.Header("Executing prepare hooks...")
+
+		if err := cc.Runner.Run(file, "prepare", cmd.Name()); err != nil {
+			return err
+		}
+
+		// Resolving dependencies
+
+		if file.Config.Inputs != nil {
+			ui.Header("Resolving dependencies...")
+
+			success, err := cc.Engine.ResolveDependencies(file)
+			if err != nil {
+				return err
+			}
+
+			if !success {
+				ui.Warn("Some of the dependencies failed to resolve, they cannot be used in expressions")
+			}
+		}
+
+		cc.evalContext = consoleEvalContext(file)
+
+		ui.NewLine()
+
+		return nil
+	})
+}
+
+// runConsole reads expressions from input until exit, end of input or interrupted, and
+// prints the result of each expression
+func (cc *consoleCmd) runConsole() error {
+	for {
+		line, err := ui.Ask(">")
+		if err != nil {
+			if err != io.EOF {
+				ui.Debug("stopped reading input: %s", err)
+			}
+
+			ui.NewLine()
+			return nil
+		}
+
+		line = strings.TrimSpace(line)
+
+		switch line {
+		case "":
+			continue
+		case "exit":
+			return nil
+		}
+
+		result, err := cc.eval(line)
+		if err != nil {
+			ui.Error("%s", err)
+			continue
+		}
+
+		ui.Output("%s", result)
+	}
+}
+
+// eval evaluates expression in context of deployment and returns the result formatted
+// as hcl
+func (cc *consoleCmd) eval(expression string) (string, error) {
+	expr, diags := hclsyntax.ParseExpression([]byte(expression), "<console>", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return "", diags
+	}
+
+	value, diags := expr.Value(cc.evalContext)
+	if diags.HasErrors() {
+		return "", diags
+	}
+
+	return formatValue(value), nil
+}
+
+// consoleEvalContext returns context to evaluate expressions for file in, the context of file
+// with values of input variables added as inputs
+func consoleEvalContext(file *loader.ParsedFile) *hcl.EvalContext {
+	ctx := file.EvalContext().NewChild()
+	ctx.Variables = map[string]cty.Value{
+		"inputs": inputValues(file),
+	}
+
+	return ctx
+}
+
+// inputValues returns the values of input variables for file, as an object. Inputs that
+// cannot be evaluated, for instance when a dependency failed to resolve, are not included.
+func inputValues(file *loader.ParsedFile) cty.Value {
+	if file.Config.Inputs == nil {
+		return cty.EmptyObjectVal
+	}
+
+	attrs, diags := file.Config.Inputs.Config.JustAttributes()
+	if diags.HasErrors() {
+		return cty.EmptyObjectVal
+	}
+
+	values := map[string]cty.Value{}
+	for name, attr := range attrs {
+		var value cty.Value
+		if diags := gohcl.DecodeExpression(attr.Expr, file.EvalContext(), &value); diags.HasErrors() {
+			ui.Debug("could not evaluate input %s: %s", name, diags)
+			continue
+		}
+
+		values[name] = value
+	}
+
+	return cty.ObjectVal(values)
+}
+
+// formatValue formats value as hcl. Values that are not known are printed as (unknown)
+func formatValue(value cty.Value) string {
+	if !value.IsWhollyKnown() {
+		return "(unknown)"
+	}
+
+	return strings.TrimSpace(string(hclwrite.Format(hclwrite.TokensForValue(value).Bytes())))
+}
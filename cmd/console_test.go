@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/avinor/tau/pkg/config"
+	"github.com/avinor/tau/pkg/config/loader"
+)
+
+const consoleContent = `
+	inputs {
+		name = "aks"
+		subnet_id = dependency.vnet.outputs.subnet_id
+		address = dependency.vnet.outputs.address
+		tags = {
+			env = "prod"
+		}
+	}
+`
+
+// newConsoleTestCmd returns console command with context of a file where dependency vnet
+// is resolved, but its address output is not known
+func newConsoleTestCmd(t *testing.T) *consoleCmd {
+	f, err := config.NewFile("/console.hcl", []byte(consoleContent))
+	assert.NoError(t, err)
+
+	f.AddToContext("dependency", cty.ObjectVal(map[string]cty.Value{
+		"vnet": cty.ObjectVal(map[string]cty.Value{
+			"outputs": cty.ObjectVal(map[string]cty.Value{
+				"subnet_id": cty.StringVal("/subnets/aks"),
+				"address":   cty.UnknownVal(cty.String),
+				"subnets":   cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+			}),
+		}),
+	}))
+
+	cfg, err := f.Config()
+	assert.NoError(t, err)
+
+	file := &loader.ParsedFile{File: f, Config: cfg}
+
+	return &consoleCmd{evalContext: consoleEvalContext(file)}
+}
+
+func TestConsoleEval(t *testing.T) {
+	cc := newConsoleTestCmd(t)
+
+	tests := []struct {
+		Expression string
+		Expected   string
+		Error      bool
+	}{
+		{`dependency.vnet.outputs.subnet_id`, `"/subnets/aks"`, false},
+		{`dependency.vnet.outputs.subnets[1]`, `"b"`, false},
+		{`length(dependency.vnet.outputs.subnets)`, `2`, false},
+		{`inputs.name`, `"aks"`, false},
+		{`inputs.subnet_id`, `"/subnets/aks"`, false},
+		{`inputs.tags.env`, `"prod"`, false},
+		{`upper(inputs.name)`, `"AKS"`, false},
+		{`dependency.vnet.outputs.address`, `(unknown)`, false},
+		{`inputs.address`, `(unknown)`, false},
+		{`"${inputs.name}-${dependency.vnet.outputs.address}"`, `(unknown)`, false},
+		{`inputs.missing`, ``, true},
+		{`dependency.aks.outputs.id`, ``, true},
+		{`inputs.name +`, ``, true},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			result, err := cc.eval(test.Expression)
+
+			if test.Error {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.Expected, result)
+		})
+	}
+}
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		Value    cty.Value
+		Expected string
+	}{
+		{cty.StringVal("value"), `"value"`},
+		{cty.NumberIntVal(5), `5`},
+		{cty.True, `true`},
+		{cty.NullVal(cty.String), `null`},
+		{cty.UnknownVal(cty.String), `(unknown)`},
+		{cty.ListVal([]cty.Value{cty.StringVal("a"), cty.UnknownVal(cty.String)}), `(unknown)`},
+		{cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}), `["a", "b"]`},
+		{cty.ObjectVal(map[string]cty.Value{"id": cty.StringVal("s1"), "size": cty.NumberIntVal(24)}), "{\n  id   = \"s1\"\n  size = 24\n}"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			assert.Equal(t, test.Expected, formatValue(test.Value))
+		})
+	}
+}
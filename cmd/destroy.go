@@ -8,6 +8,7 @@ import (
 	"github.com/avinor/tau/pkg/config/loader"
 	"github.com/avinor/tau/pkg/helpers/paths"
 	"github.com/avinor/tau/pkg/helpers/ui"
+	"github.com/avinor/tau/pkg/report"
 	"github.com/avinor/tau/pkg/shell"
 	"github.com/avinor/tau/pkg/shell/processors"
 )
@@ -49,11 +50,17 @@ func newDestroyCmd() *cobra.Command {
 		SilenceErrors:         true,
 		Args:                  cobra.MaximumNArgs(0),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := dc.meta.init(args); err != nil {
+			if err := dc.meta.init(cmd.Context(), args); err != nil {
 				return err
 			}
 
-			return dc.run(args)
+			unlock, err := dc.lock(cmd.Name())
+			if err != nil {
+				return err
+			}
+			defer unlock()
+
+			return dc.writeReport(dc.run(args))
 		},
 	}
 
@@ -61,6 +68,9 @@ func newDestroyCmd() *cobra.Command {
 	f.BoolVar(&dc.autoApprove, "auto-approve", false, "auto approve destruction")
 
 	dc.addMetaFlags(destroyCmd)
+	dc.addRefreshModulesFlag(destroyCmd)
+	dc.addWalkFlags(destroyCmd)
+	dc.addRefreshInputsFlag(destroyCmd)
 
 	return destroyCmd
 }
@@ -72,11 +82,6 @@ func (dc *destroyCmd) run(args []string) error {
 		return err
 	}
 
-	// Want to destroy them in reverse order
-	for i, j := 0, len(files)-1; i < j; i, j = i+1, j-1 {
-		files[i], files[j] = files[j], files[i]
-	}
-
 	// Verify all modules have been initialized
 	if dc.meta.noAutoInit {
 		if err := files.IsAllInitialized(); err != nil {
@@ -84,10 +89,9 @@ func (dc *destroyCmd) run(args []string) error {
 		}
 	}
 
-	for _, file := range files {
-		if err := dc.runFile(file); err != nil {
-			return err
-		}
+	// Want to destroy them in reverse order
+	if err := dc.walk(files, "destroy", true, dc.runFile); err != nil {
+		return err
 	}
 
 	ui.NewLine()
@@ -110,15 +114,14 @@ func (dc *destroyCmd) runFile(file *loader.ParsedFile) error {
 
 	// Resolving dependencies
 
-	if !paths.IsFile(file.VariableFile()) {
-		success, err := dc.resolveDependencies(file)
-		if err != nil {
-			return err
-		}
+	success, err := dc.ensureInputs(file, "destroy")
+	if err != nil {
+		return err
+	}
 
-		if !success {
-			return nil
-		}
+	if !success {
+		dc.setStatus(file, report.Skipped, dependenciesNotResolved)
+		return nil
 	}
 
 	// Executing terraform command
@@ -129,6 +132,7 @@ func (dc *destroyCmd) runFile(file *loader.ParsedFile) error {
 
 	if !paths.IsFile(file.VariableFile()) {
 		ui.Warn("No values file exists")
+		dc.setStatus(file, report.Skipped, "no input variables file exists")
 		return nil
 	}
 
@@ -145,7 +149,7 @@ func (dc *destroyCmd) runFile(file *loader.ParsedFile) error {
 		extraArgs = append(extraArgs, "-auto-approve")
 	}
 
-	if err := dc.Engine.Executor.Execute(options, "destroy", extraArgs...); err != nil {
+	if err := dc.Engine.Executor.Execute(file.Context(), options, "destroy", extraArgs...); err != nil {
 		return err
 	}
 
@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/avinor/tau/internal/templates"
+	"github.com/avinor/tau/pkg/docs"
+	"github.com/avinor/tau/pkg/helpers/ui"
+)
+
+type docsCmd struct {
+	meta
+
+	output string
+	inject string
+}
+
+var (
+	// invalidDocsFormat is returned if output format is not one of docs.Formats
+	invalidDocsFormat = errors.Errorf("invalid output format. Valid formats are %s", docs.Formats)
+
+	// injectRequiresMarkdown is returned if inject is used with another format than markdown
+	injectRequiresMarkdown = errors.Errorf("inject can only be used with markdown output format")
+
+	// docsLong is long description of docs command
+	docsLong = templates.LongDesc(`Generate documentation of all deployments in path. For each
+		deployment it documents module source and version, backend type and key, dependencies and
+		the outputs consumed from them, hooks and input variables. Values of inputs read from data
+		sources or dependencies, or with names indicating they are secrets, are not included. Expressions
+		calling functions, for instance env, are printed instead of their value.
+
+		Documentation is generated from configuration only, dependencies are not resolved. It
+		is printed as markdown or json, or injected into an existing file with --inject. The file
+		has to contain the markers <!-- BEGIN_TAU_DOCS --> and <!-- END_TAU_DOCS -->, everything
+		between them is replaced.
+		`)
+
+	// docsExample is examples for docs command
+	docsExample = templates.Examples(`
+		# Print documentation of all deployments in folder
+		tau docs -f deployments/
+
+		# Print documentation as json
+		tau docs -f deployments/ --output json
+
+		# Update documentation in README
+		tau docs -f deployments/ --inject README.md
+	`)
+)
+
+// newDocsCmd creates a new docs command
+func newDocsCmd() *cobra.Command {
+	dc := &docsCmd{}
+
+	docsCmd := &cobra.Command{
+		Use:                   "docs [-f SOURCE]",
+		Short:                 "Generate documentation of deployments",
+		Long:                  docsLong,
+		Example:               docsExample,
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		SilenceErrors:         true,
+		Args:                  cobra.MaximumNArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := dc.meta.init(cmd.Context(), args); err != nil {
+				return err
+			}
+
+			if err := dc.processArgs(args); err != nil {
+				return err
+			}
+
+			return dc.run(args)
+		},
+	}
+
+	f := docsCmd.Flags()
+	f.StringVarP(&dc.output, "output", "o", "markdown", "output format of documentation, markdown or json")
+	f.StringVar(&dc.inject, "inject", "", "inject documentation between markers in file")
+
+	dc.addMetaFlags(docsCmd)
+
+	return docsCmd
+}
+
+// processArgs process arguments and checks for invalid options or combination of arguments
+func (dc *docsCmd) processArgs(args []string) error {
+	dc.output = strings.ToLower(dc.output)
+
+	if !docs.IsValidFormat(dc.output) {
+		return invalidDocsFormat
+	}
+
+	if dc.inject != "" && dc.output != "markdown" {
+		return injectRequiresMarkdown
+	}
+
+	return nil
+}
+
+func (dc *docsCmd) run(args []string) error {
+	// load all sources
+	files, err := dc.load()
+	if err != nil {
+		return err
+	}
+
+	deployments, err := docs.Generate(files)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	if err := docs.Write(&buf, dc.output, deployments); err != nil {
+		return err
+	}
+
+	if dc.inject == "" {
+		ui.Output("%s", strings.TrimSuffix(buf.String(), "\n"))
+		return nil
+	}
+
+	content, err := ioutil.ReadFile(dc.inject)
+	if err != nil {
+		return err
+	}
+
+	result, err := docs.Inject(content, buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	if err := ioutil.WriteFile(dc.inject, result, 0644); err != nil {
+		return err
+	}
+
+	ui.Info("Documentation of %v deployments written to %s", len(deployments), dc.inject)
+
+	return nil
+}
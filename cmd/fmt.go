@@ -53,7 +53,7 @@ func newFmtCmd() *cobra.Command {
 		SilenceErrors:         true,
 		Args:                  cobra.MaximumNArgs(0),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := fc.meta.init(args); err != nil {
+			if err := fc.meta.init(cmd.Context(), args); err != nil {
 				return err
 			}
 
@@ -7,7 +7,6 @@ import (
 	"github.com/avinor/tau/internal/templates"
 	"github.com/avinor/tau/pkg/config"
 	"github.com/avinor/tau/pkg/config/loader"
-	"github.com/avinor/tau/pkg/helpers/paths"
 	"github.com/avinor/tau/pkg/helpers/ui"
 )
 
@@ -66,15 +65,21 @@ func newInitCmd() *cobra.Command {
 		SilenceErrors:         true,
 		Args:                  cobra.MaximumNArgs(0),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := ic.meta.init(args); err != nil {
+			if err := ic.meta.init(cmd.Context(), args); err != nil {
 				return err
 			}
 
+			unlock, err := ic.lock(cmd.Name())
+			if err != nil {
+				return err
+			}
+			defer unlock()
+
 			if err := ic.processArgs(args); err != nil {
 				return err
 			}
 
-			return ic.run(args)
+			return ic.writeReport(ic.run(args))
 		},
 	}
 
@@ -86,6 +91,8 @@ func newInitCmd() *cobra.Command {
 	f.StringVar(&ic.options.source.Version, "source-version", "", "override module source version, only valid together with source override")
 
 	ic.addMetaFlags(initCmd)
+	ic.addRefreshModulesFlag(initCmd)
+	ic.addWalkFlags(initCmd)
 
 	return initCmd
 }
@@ -112,9 +119,13 @@ func (ic *initCmd) processArgs(args []string) error {
 
 // run initialization command
 func (ic *initCmd) run(args []string) error {
-	if ic.options.purge {
+	// With deployment lock scope only deployments processed are purged, when they are locked
+	if ic.options.purge && ic.lockScope != deploymentLockScope {
 		ui.Debug("Purging temporary folder")
-		paths.Remove(ic.TauDir)
+
+		if err := ic.purgeTauDir(); err != nil {
+			return err
+		}
 	}
 
 	// load all sources
@@ -128,7 +139,7 @@ func (ic *initCmd) run(args []string) error {
 		return sourceMustBeAFile
 	}
 
-	if err := files.Walk(ic.runFile); err != nil {
+	if err := ic.walk(files, "init", false, ic.runFile); err != nil {
 		return err
 	}
 
@@ -140,6 +151,14 @@ func (ic *initCmd) run(args []string) error {
 func (ic *initCmd) runFile(file *loader.ParsedFile) error {
 	ui.Separator(file.Name)
 
+	if ic.options.purge && ic.lockScope == deploymentLockScope {
+		ui.Debug("Purging temporary folder for %s", file.Name)
+
+		if err := purgeDeploymentDir(file.TempDir); err != nil {
+			return err
+		}
+	}
+
 	// Running prepare hook
 
 	ui.Header("Executing prepare hooks...")
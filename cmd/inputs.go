@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/avinor/tau/internal/templates"
+	"github.com/avinor/tau/pkg/config/loader"
+	"github.com/avinor/tau/pkg/helpers/ui"
+	"github.com/avinor/tau/pkg/output"
+)
+
+type inputsCmd struct {
+	meta
+
+	output        string
+	diff          bool
+	showSensitive bool
+
+	// values are the resolved input variables, sensitive the names of inputs to redact
+	// and previous the input variables currently written to module
+	values    map[string]cty.Value
+	sensitive map[string]bool
+	previous  map[string]cty.Value
+}
+
+var (
+	// validInputsFormats are the formats input variables can be printed in
+	validInputsFormats = []string{"hcl", "json"}
+
+	// invalidInputsFormat is returned if output format is not one of validInputsFormats
+	invalidInputsFormat = errors.Errorf("invalid output format. Valid formats are %s", validInputsFormats)
+
+	// inputsRequiresSingleFile is returned if file argument references more than one deployment
+	inputsRequiresSingleFile = errors.Errorf("can only print inputs for a single file, use -f to select deployment")
+
+	// inputsNotResolved is returned if dependencies could not be resolved
+	inputsNotResolved = errors.Errorf("could not resolve dependencies, input variables cannot be printed")
+
+	// inputsLong is long description of inputs command
+	inputsLong = templates.LongDesc(`Print the input variables that would be sent to module,
+		after running prepare and resolve hooks and resolving dependencies and data sources. It
+		does not write the input variables to module, or create a plan.
+
+		Values read from data sources or dependencies, or of inputs with names indicating they
+		are secrets, are redacted unless --show-sensitive is set. Use --diff to compare with the input
+		variables currently written to module.
+		`)
+
+	// inputsExample is examples for inputs command
+	inputsExample = templates.Examples(`
+		# Print input variables for module.hcl
+		tau inputs -f module.hcl
+
+		# Print input variables as json
+		tau inputs -f module.hcl --output json
+
+		# Show which input variables changed since last plan
+		tau inputs -f module.hcl --diff
+	`)
+)
+
+// newInputsCmd creates a new inputs command
+func newInputsCmd() *cobra.Command {
+	ic := &inputsCmd{}
+
+	inputsCmd := &cobra.Command{
+		Use:                   "inputs -f SOURCE",
+		Short:                 "Print resolved input variables for module",
+		Long:                  inputsLong,
+		Example:               inputsExample,
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		SilenceErrors:         true,
+		Args:                  cobra.MaximumNArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ic.meta.init(cmd.Context(), args); err != nil {
+				return err
+			}
+
+			unlock, err := ic.lock(cmd.Name())
+			if err != nil {
+				return err
+			}
+			defer unlock()
+
+			if err := ic.processArgs(args); err != nil {
+				return err
+			}
+
+			return ic.run(args)
+		},
+	}
+
+	f := inputsCmd.Flags()
+	f.StringVarP(&ic.output, "output", "o", "hcl", "output format of input variables, hcl or json")
+	f.BoolVar(&ic.diff, "diff", false, "compare with input variables currently written to module")
+	f.BoolVar(&ic.showSensitive, "show-sensitive", false, "print values of sensitive input variables")
+
+	ic.addMetaFlags(inputsCmd)
+	inputsCmd.MarkFlagRequired("file")
+
+	return inputsCmd
+}
+
+// processArgs process arguments and checks for invalid options or combination of arguments
+func (ic *inputsCmd) processArgs(args []string) error {
+	ic.output = strings.ToLower(ic.output)
+
+	for _, format := range validInputsFormats {
+		if format == ic.output {
+			return nil
+		}
+	}
+
+	return invalidInputsFormat
+}
+
+func (ic *inputsCmd) run(args []string) error {
+	// load all sources
+	files, err := ic.load()
+	if err != nil {
+		return err
+	}
+
+	if len(files) != 1 {
+		return inputsRequiresSingleFile
+	}
+
+	if err := ic.runWithHooks(files[0], "inputs", ic.runFile); err != nil {
+		return err
+	}
+
+	ui.NewLine()
+
+	if ic.diff {
+		return ic.printDiff()
+	}
+
+	format := "tfvars"
+	if ic.output == "json" {
+		format = "json"
+	}
+
+	var buf bytes.Buffer
+
+	options := &output.Options{ShowSensitive: ic.showSensitive}
+	if err := output.Write(&buf, format, output.NewOutputs(ic.values, ic.sensitive), options); err != nil {
+		return err
+	}
+
+	if buf.Len() > 0 {
+		ui.Output("%s", strings.TrimSuffix(buf.String(), "\n"))
+	}
+
+	return nil
+}
+
+func (ic *inputsCmd) runFile(file *loader.ParsedFile) error {
+	ui.Separator(file.Name)
+
+	// Running prepare hook
+
+	ui.Header("Executing prepare hooks...")
+
+	if err := ic.Runner.Run(file, "prepare", "inputs"); err != nil {
+		return err
+	}
+
+	// Resolving dependencies, input variables are not written so they can be compared
+	// with the input variables currently written to module
+
+	success, err := ic.resolveWithHooks(file, "inputs", false)
+	if err != nil {
+		return err
+	}
+
+	if !success {
+		return inputsNotResolved
+	}
+
+	values, err := ic.Engine.InputVariables(file)
+	if err != nil {
+		return err
+	}
+
+	sensitive, err := file.SensitiveInputs()
+	if err != nil {
+		return err
+	}
+
+	previous, err := ic.Engine.ReadInputVariables(file)
+	if err != nil {
+		return err
+	}
+
+	ic.values = values
+	ic.sensitive = sensitive
+	ic.previous = previous
+
+	return nil
+}
+
+// printDiff prints input variables that are added, removed or changed compared to input
+// variables currently written to module
+func (ic *inputsCmd) printDiff() error {
+	if ic.previous == nil {
+		ui.Warn("Input variables have not been written to module, all input variables are new")
+	}
+
+	names := []string{}
+	for name := range ic.values {
+		names = append(names, name)
+	}
+	for name := range ic.previous {
+		if _, ok := ic.values[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	changes := 0
+
+	for _, name := range names {
+		current, hasCurrent := ic.values[name]
+		previous, hasPrevious := ic.previous[name]
+
+		switch {
+		case !hasPrevious:
+			ui.Output("+ %s = %s", name, ic.formatInput(name, current))
+		case !hasCurrent:
+			ui.Output("- %s = %s", name, ic.formatInput(name, previous))
+		case !previous.RawEquals(current):
+			ui.Output("~ %s = %s -> %s", name, ic.formatInput(name, previous), ic.formatInput(name, current))
+		default:
+			continue
+		}
+
+		changes++
+	}
+
+	if changes == 0 {
+		ui.Info("No changes to input variables")
+	}
+
+	return nil
+}
+
+// formatInput formats value of input variable as hcl, or returns output.SensitiveValue
+// if input is sensitive and sensitive values should not be shown
+func (ic *inputsCmd) formatInput(name string, value cty.Value) string {
+	if ic.sensitive[name] && !ic.showSensitive {
+		return output.SensitiveValue
+	}
+
+	// Indent lines of multi-line values so they line up with name of input
+	return strings.Replace(formatValue(value), "\n", "\n  ", -1)
+}
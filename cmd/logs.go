@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/avinor/tau/internal/templates"
+	"github.com/avinor/tau/pkg/config/loader"
+	"github.com/avinor/tau/pkg/helpers/paths"
+	"github.com/avinor/tau/pkg/helpers/ui"
+)
+
+type logsCmd struct {
+	file string
+	last bool
+}
+
+var (
+	// logsFileRequired is returned if file is not set
+	logsFileRequired = errors.Errorf("file is required, use -f to select deployment")
+
+	// logsLong is long description of logs command
+	logsLong = templates.LongDesc(`Print logs from commands executed for a deployment. All
+		commands tau executes for a deployment, terraform and hooks, are written to a log
+		file in temporary .tau directory together with their output. Secrets are redacted
+		before written to log.
+
+		By default it prints all logs for deployment, use --last to only print log from
+		last command.
+		`)
+
+	// logsExample is examples for logs command
+	logsExample = templates.Examples(`
+		# Print all logs for a deployment
+		tau logs -f module.hcl
+
+		# Print log from last command
+		tau logs -f module.hcl --last
+	`)
+)
+
+// newLogsCmd creates a new logs command
+func newLogsCmd() *cobra.Command {
+	lc := &logsCmd{}
+
+	logsCmd := &cobra.Command{
+		Use:                   "logs -f SOURCE [--last]",
+		Short:                 "Print logs from commands executed for a deployment",
+		Long:                  logsLong,
+		Example:               logsExample,
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		SilenceErrors:         true,
+		Args:                  cobra.MaximumNArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return lc.run(args)
+		},
+	}
+
+	f := logsCmd.Flags()
+	f.StringVarP(&lc.file, "file", "f", "", "deployment to print logs for")
+	f.BoolVar(&lc.last, "last", false, "only print log from last command")
+
+	return logsCmd
+}
+
+func (lc *logsCmd) run(args []string) error {
+	if lc.file == "" {
+		return logsFileRequired
+	}
+
+	if workingDir == "" {
+		workingDir = paths.WorkingDir
+	}
+
+	logDir := paths.Join(workingDir, paths.TauPath, filepath.Base(lc.file), loader.LogDirName)
+
+	logs, err := filepath.Glob(filepath.Join(logDir, "*.log"))
+	if err != nil {
+		return err
+	}
+
+	if len(logs) == 0 {
+		ui.Info("No logs found for %s", filepath.Base(lc.file))
+		return nil
+	}
+
+	// Log files are prefixed with timestamp, so sorting by name sorts them in order
+	sort.Strings(logs)
+
+	if lc.last {
+		logs = logs[len(logs)-1:]
+	}
+
+	for _, log := range logs {
+		content, err := ioutil.ReadFile(log)
+		if err != nil {
+			return err
+		}
+
+		ui.Header(filepath.Base(log))
+		ui.Output("%s", strings.TrimRight(string(content), "\n"))
+	}
+
+	return nil
+}
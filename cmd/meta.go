@@ -1,28 +1,65 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/fatih/color"
+	"github.com/hashicorp/hcl/v2"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/avinor/tau/pkg/config"
 	"github.com/avinor/tau/pkg/config/loader"
 	"github.com/avinor/tau/pkg/getter"
+	"github.com/avinor/tau/pkg/helpers/lock"
 	"github.com/avinor/tau/pkg/helpers/paths"
 	"github.com/avinor/tau/pkg/helpers/ui"
 	"github.com/avinor/tau/pkg/hooks"
 	hooksdef "github.com/avinor/tau/pkg/hooks/def"
+	"github.com/avinor/tau/pkg/report"
 	"github.com/avinor/tau/pkg/shell"
 	"github.com/avinor/tau/pkg/shell/processors"
 	"github.com/avinor/tau/pkg/terraform"
 	"github.com/avinor/tau/pkg/terraform/def"
 )
 
+const (
+	// directoryLockScope locks entire tau directory while command is running
+	directoryLockScope = "directory"
+
+	// deploymentLockScope only locks the deployments that are processed
+	deploymentLockScope = "deployment"
+)
+
 var (
 	// noSourceInPath is returned when there are no source files in path
 	noSourceInPath = errors.Errorf("no source files found in path")
+
+	// upstreamFailed is the reason deployments are skipped when a deployment they depend on failed
+	upstreamFailed = "upstream dependency failed"
+
+	// walkStopped is the reason deployments are skipped when they did not run because an
+	// unrelated deployment failed, and keep-going is not set
+	walkStopped = "not run, walk stopped after error"
+
+	// dependenciesNotResolved is the reason deployments are skipped when dependencies cannot be resolved
+	dependenciesNotResolved = "dependencies could not be resolved"
+
+	// interrupted is returned for deployments not started because tau was interrupted
+	interrupted = errors.Errorf("interrupted, deployment not started")
+
+	// inputVariablesInvalid is returned if input variables are not valid for module
+	inputVariablesInvalid = errors.Errorf("input variables are not valid for module")
+
+	// lockScopeIncorrect is returned if lock scope is not a valid value
+	lockScopeIncorrect = errors.Errorf("lock-scope has to be one of: %s, %s", directoryLockScope, deploymentLockScope)
 )
 
 type meta struct {
@@ -30,11 +67,28 @@ type meta struct {
 	maxDependencyDepth int
 	files              []string
 	noAutoInit         bool
+	refreshInputs      bool
+	refreshModules     bool
+	lockScope          string
+	lockTimeout        time.Duration
+	reportFile         string
+	keepGoing          bool
+	deploymentTimeout  time.Duration
 
-	Engine *terraform.Engine
-	Getter *getter.Client
-	Loader *loader.Loader
-	Runner *hooks.Runner
+	// failed are deployments that failed before walk, they are reported as failed by walk
+	// without running again
+	failed map[*loader.ParsedFile]error
+
+	// ctx is done when tau is interrupted, no new deployments are started then
+	ctx context.Context
+
+	report *report.Report
+
+	Engine      *terraform.Engine
+	Getter      *getter.Client
+	ModuleCache *getter.ModuleCache
+	Loader      *loader.Loader
+	Runner      *hooks.Runner
 
 	TauDir   string
 	CacheDir string
@@ -47,7 +101,14 @@ type initOptions struct {
 	reconfigure bool
 }
 
-func (m *meta) init(args []string) error {
+func (m *meta) init(ctx context.Context, args []string) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	m.ctx = ctx
+	m.failed = map[*loader.ParsedFile]error{}
+
 	if workingDir == "" {
 		workingDir = paths.WorkingDir
 	}
@@ -64,6 +125,7 @@ func (m *meta) init(args []string) error {
 		}
 
 		m.Getter = getter.New(options)
+		m.ModuleCache = getter.NewModuleCache(paths.Join(m.CacheDir, getter.ModulesDirName), m.Getter, m.refreshModules)
 	}
 
 	{
@@ -91,6 +153,14 @@ func (m *meta) init(args []string) error {
 		})
 	}
 
+	if m.reportFile != "" {
+		if err := report.ValidatePath(m.reportFile); err != nil {
+			return err
+		}
+	}
+
+	m.report = report.New("")
+
 	ui.Debug("tau dir: %s", m.TauDir)
 	ui.Debug("http timeout: %s", m.timeout)
 	ui.Debug("max dependency depth: %s", m.maxDependencyDepth)
@@ -106,6 +176,256 @@ func (m *meta) addMetaFlags(cmd *cobra.Command) {
 	f.StringArrayVarP(&m.files, "file", "f", []string{"."}, "file or directory to run configuration for")
 	f.BoolVar(&m.noAutoInit, "no-auto-init", false, "disable auto init")
 	f.IntVar(&m.maxDependencyDepth, "max-dependency-depth", 1, "defines max dependency depth when traversing dependencies") //nolint:lll
+	f.StringVar(&m.lockScope, "lock-scope", directoryLockScope, "lock entire tau directory or only deployments processed (directory, deployment)")
+	f.DurationVar(&m.lockTimeout, "lock-timeout", 0, "time to wait for lock held by another tau process")
+}
+
+// lock acquires the lock for tau directory, to prevent other tau processes from changing
+// it while running. Returns a function to release the lock. With deployment lock scope it
+// does not lock the directory, instead each deployment is locked in runWithHooks. The two
+// scopes exclude each other, directory cannot be locked while a deployment is locked, and
+// deployments cannot be locked while directory is locked.
+func (m *meta) lock(command string) (func(), error) {
+	switch m.lockScope {
+	case directoryLockScope:
+	case deploymentLockScope:
+		return func() {}, nil
+	default:
+		return nil, lockScopeIncorrect
+	}
+
+	// Directory cannot be locked while another process holds lock for a deployment
+	return acquireLock(filepath.Join(m.TauDir, lock.FileName), command, m.lockTimeout, filepath.Join(m.TauDir, "*"+lock.Extension))
+}
+
+// acquireLock acquires lock in path and returns function to release it. Lock is not acquired
+// while any lock file matching conflicts is held.
+func acquireLock(path, command string, timeout time.Duration, conflicts ...string) (func(), error) {
+	l := lock.New(path, conflicts...)
+
+	if err := l.Acquire(command, timeout); err != nil {
+		return nil, err
+	}
+
+	return func() {
+		if err := l.Release(); err != nil {
+			ui.Warn("Failed to release lock %s: %s", path, err)
+		}
+	}, nil
+}
+
+// purgeTauDir removes all deployments from tau directory. Lock files are kept, as they
+// could be held by this or other processes.
+func (m *meta) purgeTauDir() error {
+	entries, err := ioutil.ReadDir(m.TauDir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), lock.Extension) {
+			continue
+		}
+
+		path := filepath.Join(m.TauDir, entry.Name())
+
+		if entry.IsDir() {
+			if err := purgeDeploymentDir(path); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// purgeDeploymentDir removes everything in temporary directory of a deployment, except
+// the logs from previous executions
+func purgeDeploymentDir(dir string) error {
+	entries, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() && entry.Name() == loader.LogDirName {
+			continue
+		}
+
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// addRefreshInputsFlag adds the refresh-inputs argument to commands that use ensureInputs
+func (m *meta) addRefreshInputsFlag(cmd *cobra.Command) {
+	f := cmd.Flags()
+	f.BoolVar(&m.refreshInputs, "refresh-inputs", false, "always resolve dependencies and regenerate input variables")
+}
+
+// addRefreshModulesFlag adds the refresh-modules argument to commands that initialize modules
+func (m *meta) addRefreshModulesFlag(cmd *cobra.Command) {
+	f := cmd.Flags()
+	f.BoolVar(&m.refreshModules, "refresh-modules", false, "download modules again instead of using module cache")
+}
+
+// addWalkFlags adds arguments to commands that run for each deployment
+func (m *meta) addWalkFlags(cmd *cobra.Command) {
+	f := cmd.Flags()
+	f.StringVar(&m.reportFile, "report", "", "write result for each deployment to report, junit (.xml) or json (.json)")
+	f.BoolVar(&m.keepGoing, "keep-going", false, "continue with deployments that do not depend on a failed deployment")
+	f.DurationVar(&m.deploymentTimeout, "timeout-per-deployment", 0, "stop commands for a deployment if it does not complete within timeout")
+}
+
+// walk runs fn with hooks for all files in dependency order, or reverse order if reverse
+// is set. With keep-going it continues with files not depending on a failed file and
+// prints a summary at the end, otherwise it stops at first failure.
+func (m *meta) walk(files loader.ParsedFileCollection, command string, reverse bool, fn func(file *loader.ParsedFile) error) error {
+	result := files.WalkWithOptions(func(file *loader.ParsedFile) error {
+		if err, ok := m.failed[file]; ok {
+			return err
+		}
+
+		return m.runWithHooks(file, command, fn)
+	}, &loader.WalkOptions{
+		KeepGoing: m.keepGoing,
+		Reverse:   reverse,
+	})
+
+	for _, file := range result.Skipped {
+		deployment := m.report.Start(file.Name, command)
+		deployment.SetStatus(report.Skipped, upstreamFailed)
+		deployment.Finish(nil, "")
+	}
+
+	for _, file := range result.Stopped {
+		deployment := m.report.Start(file.Name, command)
+		deployment.SetStatus(report.Skipped, walkStopped)
+		deployment.Finish(nil, "")
+	}
+
+	if m.keepGoing {
+		printWalkSummary(result)
+	}
+
+	return result.Err()
+}
+
+// printWalkSummary prints which deployments succeeded, failed and were skipped
+func printWalkSummary(result *loader.WalkResult) {
+	ui.Header("Summary:")
+
+	for _, file := range result.Succeeded {
+		ui.Info("- %s: %s", file.Name, color.GreenString("succeeded"))
+	}
+
+	for _, file := range result.Failed {
+		ui.Info("- %s: %s: %s", file.Name, color.RedString("failed"), result.Errors[file])
+	}
+
+	for _, file := range result.Skipped {
+		ui.Info("- %s: %s", file.Name, color.YellowString("skipped, %s", upstreamFailed))
+	}
+
+	for _, file := range result.Stopped {
+		ui.Info("- %s: %s", file.Name, color.YellowString(walkStopped))
+	}
+
+	ui.NewLine()
+	ui.Info(color.New(color.Bold).Sprintf("%v succeeded, %v failed, %v skipped", len(result.Succeeded), len(result.Failed), len(result.Skipped)+len(result.Stopped)))
+}
+
+// writeReport writes the report to report file, if set. Takes the error from running command
+// and returns it, so it can wrap the run function. If writing report fails when command
+// succeeded that error is returned instead.
+func (m *meta) writeReport(err error) error {
+	if m.reportFile == "" || m.report == nil {
+		return err
+	}
+
+	m.report.Finish()
+
+	if writeErr := m.report.Write(paths.Abs(workingDir, m.reportFile)); writeErr != nil {
+		if err != nil {
+			ui.Error("Failed writing report: %s", writeErr)
+			return err
+		}
+
+		return writeErr
+	}
+
+	ui.Info("- Report written to %s", m.reportFile)
+
+	return err
+}
+
+// setStatus sets status of deployment for file in report, with a message describing why
+func (m *meta) setStatus(file *loader.ParsedFile, status report.Status, msg string, args ...interface{}) {
+	if deployment := m.report.Get(file.Name); deployment != nil {
+		deployment.SetStatus(status, msg, args...)
+	}
+}
+
+// setPlanSummary sets the summary of plan for file in report
+func (m *meta) setPlanSummary(file *loader.ParsedFile, summary *def.PlanSummary) {
+	if deployment := m.report.Get(file.Name); deployment != nil {
+		deployment.Plan = summary
+	}
+}
+
+// planSummary reads the changes in plan for file
+func (m *meta) planSummary(file *loader.ParsedFile) (*def.PlanSummary, error) {
+	planProcessor := m.Engine.Executor.NewPlanProcessor()
+
+	options := &shell.Options{
+		WorkingDirectory: file.ModuleDir(),
+		Stdout:           shell.Processors(planProcessor),
+		Stderr:           shell.Processors(processors.NewUI(ui.Error)),
+		Env:              file.Env,
+		SensitiveStdout:  true,
+	}
+
+	if err := m.Engine.Executor.Execute(file.Context(), options, "show", "-json", file.PlanFile()); err != nil {
+		return nil, err
+	}
+
+	return planProcessor.GetPlanSummary()
+}
+
+// validateInputs validates input variables against variables declared in module, so errors
+// in inputs are reported with position in tau file before running terraform
+func (m *meta) validateInputs(file *loader.ParsedFile) error {
+	diags, err := m.Engine.ValidateInputVariables(file)
+	if err != nil {
+		return err
+	}
+
+	for _, diag := range diags {
+		msg := fmt.Sprintf("%s: %s; %s", diag.Subject, diag.Summary, diag.Detail)
+
+		if diag.Severity == hcl.DiagError {
+			ui.Error("%s", msg)
+		} else {
+			ui.Warn("%s", msg)
+		}
+	}
+
+	if diags.HasErrors() {
+		return inputVariablesInvalid
+	}
+
+	return nil
 }
 
 // load wraps the Loader.Load function to load all files and return to caller.
@@ -130,10 +450,130 @@ func (m *meta) load() (loader.ParsedFileCollection, error) {
 	return files, nil
 }
 
-// resolveDependencies resolves the dependencies for all files
-func (m *meta) resolveDependencies(file *loader.ParsedFile) (bool, error) {
+// runWithHooks runs the fn function for file and executes error hooks if it fails. Always
+// hooks are executed after fn no matter if it failed or not. Error message and exit code are
+// available for hooks in environment variables. With deployment lock scope the deployment
+// is locked while running.
+func (m *meta) runWithHooks(file *loader.ParsedFile, command string, fn func(file *loader.ParsedFile) error) error {
+	return m.runDeployment(file, command, true, fn)
+}
+
+// runBeforeHooks runs fn for file as runWithHooks, but only executes error and always hooks
+// if it fails. Used for steps running for a deployment before the command runs, when always
+// hooks should run after the command instead.
+func (m *meta) runBeforeHooks(file *loader.ParsedFile, command string, fn func(file *loader.ParsedFile) error) error {
+	return m.runDeployment(file, command, false, fn)
+}
+
+// runDeployment runs fn for file with log, report and lock, see runWithHooks. Always hooks
+// are only executed if fn succeeded when always is set.
+func (m *meta) runDeployment(file *loader.ParsedFile, command string, always bool, fn func(file *loader.ParsedFile) error) (err error) {
+	defer ui.WithContext(ui.DeploymentContext, file.Name)()
+	defer ui.WithContext(ui.PhaseContext, command)()
+
+	log, logErr := shell.OpenLog(file.LogFile(command, time.Now()))
+	if logErr != nil {
+		return logErr
+	}
+	defer log.Close()
+	defer shell.SetLog(log)()
+
+	m.report.Command = command
+	deployment := m.report.Start(file.Name, command)
+	defer func() { deployment.Finish(err, log.Stderr()) }()
+
+	if m.ctx.Err() != nil {
+		return interrupted
+	}
+
+	ctx, cancel := m.ctx, func() {}
+	if m.deploymentTimeout > 0 {
+		ctx, cancel = context.WithTimeout(m.ctx, m.deploymentTimeout)
+	}
+	defer cancel()
+
+	file.SetContext(ctx)
+
+	if m.lockScope == deploymentLockScope {
+		unlock, err := acquireLock(file.LockFile(), command, m.lockTimeout, filepath.Join(m.TauDir, lock.FileName))
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+
+	err = fn(file)
+
+	if err != nil && ctx.Err() == context.DeadlineExceeded && m.ctx.Err() == nil {
+		err = errors.Wrapf(err, "deployment did not complete within %s", m.deploymentTimeout)
+	}
+
+	// Error and always hooks should still run if deployment timed out
+	file.SetContext(m.ctx)
+
+	file.Env[hooks.ExitCodeEnv] = "0"
+
+	if err != nil {
+		exitCode := 1
+
+		var exitErr *shell.ExitError
+		if errors.As(err, &exitErr) {
+			exitCode = exitErr.ExitCode
+		}
+
+		file.Env[hooks.ErrorMessageEnv] = err.Error()
+		file.Env[hooks.ExitCodeEnv] = strconv.Itoa(exitCode)
+
+		if hookErr := m.runOptionalHooks(file, "error", command); hookErr != nil {
+			ui.Error("Failed executing error hooks: %s", hookErr)
+		}
+	}
+
+	if err == nil && !always {
+		return nil
+	}
+
+	if hookErr := m.runOptionalHooks(file, "always", command); hookErr != nil {
+		if err != nil {
+			ui.Error("Failed executing always hooks: %s", hookErr)
+			return err
+		}
+
+		return hookErr
+	}
+
+	return err
+}
+
+// runOptionalHooks runs hooks for event, but only prints header if there are any hooks to run.
+// Used for events that seldom have any hooks defined.
+func (m *meta) runOptionalHooks(file *loader.ParsedFile, event, command string) error {
+	if !m.Runner.HasHooks(file, event, command) {
+		return nil
+	}
+
+	ui.Header(fmt.Sprintf("Executing %s hooks...", event))
+
+	return m.Runner.Run(file, event, command)
+}
+
+// resolveDependencies resolves the dependencies for all files and writes input variables
+// to module. Runs the pre_resolve and post_resolve hooks before and after resolving dependencies
+func (m *meta) resolveDependencies(file *loader.ParsedFile, command string) (bool, error) {
+	return m.resolveWithHooks(file, command, true)
+}
+
+// resolveWithHooks resolves dependencies for file as resolveDependencies, but only writes
+// input variables to module if write is set
+func (m *meta) resolveWithHooks(file *loader.ParsedFile, command string, write bool) (bool, error) {
+	defer ui.WithContext(ui.PhaseContext, "resolve")()
+
+	if err := m.runOptionalHooks(file, "pre_resolve", command); err != nil {
+		return false, err
+	}
+
 	if file.Config.Inputs == nil {
-		return true, nil
+		return true, m.runOptionalHooks(file, "post_resolve", command)
 	}
 
 	ui.Header("Resolving dependencies...")
@@ -152,13 +592,170 @@ func (m *meta) resolveDependencies(file *loader.ParsedFile) (bool, error) {
 		return false, nil
 	}
 
-	if err := m.Engine.WriteInputVariables(file); err != nil {
+	if !write {
+		return true, m.runOptionalHooks(file, "post_resolve", command)
+	}
+
+	previous, err := file.ReadInputsHash()
+	if err != nil {
+		return false, err
+	}
+
+	current, err := file.InputsHash()
+	if err != nil {
+		return false, err
+	}
+
+	changed, err := m.Engine.WriteInputVariables(file)
+	if err != nil {
+		return false, err
+	}
+
+	if previous != nil && previous.Dependencies != current.Dependencies {
+		ui.Info("- Values from dependencies changed since input variables were written")
+	}
+
+	if len(changed) > 0 {
+		ui.Warn("Input variables changed since last written: %s", strings.Join(changed, ", "))
+	}
+
+	if err := file.WriteInputsHash(); err != nil {
+		return false, err
+	}
+
+	if err := m.runOptionalHooks(file, "post_resolve", command); err != nil {
 		return false, err
 	}
 
 	return true, nil
 }
 
+// ensureInputs makes sure the input variables file is up to date before running commands
+// that do not always resolve dependencies. Dependencies are resolved if input variables file
+// does not exist, refresh-inputs is set, configuration changed since file was written, or if
+// it has dependencies or data sources, as their values could have changed.
+//
+// If resolving fails, and configuration has not changed since input variables file was
+// written, it continues with existing file. If configuration changed existing file is
+// outdated, so it returns false and the deployment is skipped.
+func (m *meta) ensureInputs(file *loader.ParsedFile, command string) (bool, error) {
+	if !paths.IsFile(file.VariableFile()) {
+		return m.resolveDependencies(file, command)
+	}
+
+	stale, configChanged, err := m.inputsStale(file)
+	if err != nil {
+		return false, err
+	}
+
+	if !stale && !m.refreshInputs {
+		ui.Debug("input variables for %s are up to date", file.Name)
+		return true, nil
+	}
+
+	success, err := m.resolveDependencies(file, command)
+	if err != nil {
+		return false, err
+	}
+
+	if !success {
+		if configChanged {
+			ui.Warn("Could not resolve dependencies, and existing input variables are outdated")
+			return false, nil
+		}
+
+		ui.Warn("Could not resolve dependencies, using existing input variables")
+	}
+
+	return true, nil
+}
+
+// inputsStale returns true if input variables file could be stale. That is if configuration
+// has changed since file was written or file has dependencies or data sources. Second return
+// value is true if configuration changed, or it is unknown what file was written from.
+func (m *meta) inputsStale(file *loader.ParsedFile) (bool, bool, error) {
+	previous, err := file.ReadInputsHash()
+	if err != nil {
+		return false, false, err
+	}
+
+	if previous == nil {
+		return true, true, nil
+	}
+
+	current, err := file.InputsHash()
+	if err != nil {
+		return false, false, err
+	}
+
+	if previous.Config != current.Config {
+		ui.Info("- Configuration changed since input variables were written")
+		return true, true, nil
+	}
+
+	return len(file.Config.Dependencies) > 0 || len(file.Config.Datas) > 0, false, nil
+}
+
+// readOutputs reads the output values from module and makes them available for hooks. Only
+// reads outputs if there are any finish or always hooks that could use them.
+func (m *meta) readOutputs(file *loader.ParsedFile, command string) error {
+	if !m.Runner.HasHooks(file, "finish", command) && !m.Runner.HasHooks(file, "always", command) {
+		return nil
+	}
+
+	outputProcessor := m.Engine.Executor.NewOutputProcessor()
+
+	options := &shell.Options{
+		WorkingDirectory: file.ModuleDir(),
+		Stdout:           shell.Processors(outputProcessor),
+		Stderr:           shell.Processors(processors.NewUI(ui.Error)),
+		Env:              file.Env,
+		SensitiveStdout:  true,
+	}
+
+	if err := m.Engine.Executor.Execute(file.Context(), options, "output", "-json"); err != nil {
+		return err
+	}
+
+	values, err := outputProcessor.GetOutput()
+	if err != nil {
+		return err
+	}
+
+	return file.SetOutputs(values)
+}
+
+// createPlan runs terraform plan for file and writes the fingerprint of inputs next to plan
+// file, so apply can verify that nothing has changed since plan was created.
+func (m *meta) createPlan(file *loader.ParsedFile, destroy bool) error {
+	if !destroy {
+		if err := m.validateInputs(file); err != nil {
+			return err
+		}
+	}
+
+	options := &shell.Options{
+		WorkingDirectory: file.ModuleDir(),
+		Stdout:           shell.Processors(processors.NewUI(ui.Info)),
+		Stderr:           shell.Processors(processors.NewUI(ui.Error)),
+		Env:              file.Env,
+		Retry:            file.RetryPolicy(),
+	}
+
+	extraArgs := getExtraArgs(m.Engine.Compatibility.GetInvalidArgs("plan")...)
+	extraArgs = append(extraArgs, fmt.Sprintf("-out=%s", file.PlanFile()))
+
+	if destroy {
+		extraArgs = append(extraArgs, "-destroy")
+	}
+
+	if err := m.Engine.Executor.Execute(file.Context(), options, "plan", extraArgs...); err != nil {
+		return err
+	}
+
+	return file.WriteFingerprint(destroy)
+}
+
 // autoInit can be called by any command to auto initialize the module
 func (m *meta) autoInit(file *loader.ParsedFile) error {
 	if file.IsInitialized() {
@@ -179,6 +776,8 @@ func (m *meta) runInit(file *loader.ParsedFile, options *initOptions) error {
 		options = &initOptions{}
 	}
 
+	defer ui.WithContext(ui.PhaseContext, "init")()
+
 	ui.Header("Initializing tau...")
 
 	// Loading module
@@ -196,7 +795,7 @@ func (m *meta) runInit(file *loader.ParsedFile, options *initOptions) error {
 			ui.Info("- Loading module from %s", module.Source)
 		}
 
-		if err := m.Getter.Get(module.GetSource(), file.ModuleDir()); err != nil {
+		if err := m.ModuleCache.Get(module.GetSource(), file.ModuleDir()); err != nil {
 			return err
 		}
 	}
@@ -222,6 +821,7 @@ func (m *meta) runInit(file *loader.ParsedFile, options *initOptions) error {
 		Stdout:           shell.Processors(processors.NewUI(ui.Info)),
 		Stderr:           shell.Processors(processors.NewUI(ui.Error)),
 		Env:              file.Env,
+		Retry:            file.RetryPolicy(),
 	}
 
 	extraArgs := getExtraArgs(m.Engine.Compatibility.GetInvalidArgs("init")...)
@@ -230,7 +830,7 @@ func (m *meta) runInit(file *loader.ParsedFile, options *initOptions) error {
 		extraArgs = append(extraArgs, "-reconfigure", "-force-copy")
 	}
 
-	if err := m.Engine.Executor.Execute(shellOptions, "init", extraArgs...); err != nil {
+	if err := m.Engine.Executor.Execute(file.Context(), shellOptions, "init", extraArgs...); err != nil {
 		return err
 	}
 
@@ -1,20 +1,22 @@
 package cmd
 
 import (
-	"fmt"
+	"bytes"
+	"os"
+	"strconv"
 	"strings"
 
 	"github.com/fatih/color"
-	"github.com/ghodss/yaml"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 	"github.com/zclconf/go-cty/cty"
-	ctyjson "github.com/zclconf/go-cty/cty/json"
 
 	"github.com/avinor/tau/internal/templates"
 	"github.com/avinor/tau/pkg/config/loader"
 	"github.com/avinor/tau/pkg/helpers/paths"
 	"github.com/avinor/tau/pkg/helpers/ui"
+	"github.com/avinor/tau/pkg/output"
+	"github.com/avinor/tau/pkg/report"
 	"github.com/avinor/tau/pkg/shell"
 	"github.com/avinor/tau/pkg/shell/processors"
 )
@@ -22,22 +24,43 @@ import (
 type outputCmd struct {
 	meta
 
-	output string
+	output        string
+	filter        []string
+	raw           string
+	showSensitive bool
+
+	// outputs are outputs read from each deployment, by deployment name
+	outputs map[string]output.Outputs
 }
 
 var (
-	validOutputFormats = []string{"json", "yaml", "env", "plain"}
-
-	// outputRequiresSingleFile is returned if using output argument and it tries to process multiple files
-	outputRequiresSingleFile = errors.Errorf("can only process a single file when using output argument")
+	validOutputFormats = append([]string{"plain"}, output.Formats...)
 
-	// outputRequiresSingleFile is returned if using output argument and it tries to process multiple files
+	// invalidOutputFormat is returned if output format is not one of validOutputFormats
 	invalidOutputFormat = errors.Errorf("invalid output format. Valid formats are %s", validOutputFormats)
 
+	// rawAndOutputTogether is returned if raw is defined together with an output format
+	rawAndOutputTogether = errors.Errorf("raw and output arguments cannot be defined together")
+
+	// rawAndFilterTogether is returned if raw is defined together with filter
+	rawAndFilterTogether = errors.Errorf("raw and filter arguments cannot be defined together")
+
+	// rawPathIncorrect is returned if raw path does not reference an output in a deployment
+	rawPathIncorrect = errors.Errorf("raw has to reference an output as deployment.output[.path]")
+
+	// rawValueSensitive is returned if raw references a sensitive output without show-sensitive
+	rawValueSensitive = errors.Errorf("raw references a sensitive output, use --show-sensitive to print it")
+
 	// outputLong is long description of output command
 	outputLong = templates.LongDesc(`Print all the output variables from a module.
 		If including the --output flag it will print output in specified format.
-		It supports json, yaml, env and plain as output format
+		It supports plain, json, yaml, env, dotenv, export, tfvars, github and
+		terraform-json as output format. When path contains more than one deployment
+		the output is an object with outputs for each deployment, keyed by deployment
+		name. Values of sensitive outputs are masked unless --show-sensitive is set.
+
+		Use --raw to print a single string, number or bool value without quotes,
+		for instance to use in shell scripts.
 		`)
 
 	// outputExample is examples for output command
@@ -47,12 +70,23 @@ var (
 
 		# Print output from module.hcl in json format
 		tau output -f module.hcl --output json
+
+		# Print output from vnet and aks deployments in folder as yaml
+		tau output --output yaml --filter vnet,aks
+
+		# Print a single value from vnet deployment
+		tau output --raw vnet.subnets.0.id
+
+		# Write outputs from module.hcl as terraform variables
+		tau output -f module.hcl --output tfvars > module.tfvars
 	`)
 )
 
 // newOutputCmd creates a new output command
 func newOutputCmd() *cobra.Command {
-	oc := &outputCmd{}
+	oc := &outputCmd{
+		outputs: map[string]output.Outputs{},
+	}
 
 	outputCmd := &cobra.Command{
 		Use:                   "output [-f SOURCE]",
@@ -64,22 +98,34 @@ func newOutputCmd() *cobra.Command {
 		SilenceErrors:         true,
 		Args:                  cobra.MaximumNArgs(0),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := oc.meta.init(args); err != nil {
+			if err := oc.meta.init(cmd.Context(), args); err != nil {
+				return err
+			}
+
+			unlock, err := oc.lock(cmd.Name())
+			if err != nil {
 				return err
 			}
+			defer unlock()
 
 			if err := oc.processArgs(args); err != nil {
 				return err
 			}
 
-			return oc.run(args)
+			return oc.writeReport(oc.run(args))
 		},
 	}
 
 	f := outputCmd.Flags()
 	f.StringVarP(&oc.output, "output", "o", "plain", "output format of variables")
+	f.StringSliceVar(&oc.filter, "filter", nil, "only print output from these deployments")
+	f.StringVar(&oc.raw, "raw", "", "print a single value, referenced as deployment.output[.path]")
+	f.BoolVar(&oc.showSensitive, "show-sensitive", false, "print values of sensitive outputs")
 
 	oc.addMetaFlags(outputCmd)
+	oc.addRefreshModulesFlag(outputCmd)
+	oc.addWalkFlags(outputCmd)
+	oc.addRefreshInputsFlag(outputCmd)
 
 	return outputCmd
 }
@@ -100,11 +146,19 @@ func (oc *outputCmd) processArgs(args []string) error {
 		return invalidOutputFormat
 	}
 
+	if oc.raw != "" && oc.output != "plain" {
+		return rawAndOutputTogether
+	}
+
+	if oc.raw != "" && len(oc.filter) > 0 {
+		return rawAndFilterTogether
+	}
+
 	return nil
 }
 
 func (oc *outputCmd) shouldProcessOutput() bool {
-	return oc.output != "plain"
+	return oc.output != "plain" || oc.raw != ""
 }
 
 func (oc *outputCmd) run(args []string) error {
@@ -114,9 +168,23 @@ func (oc *outputCmd) run(args []string) error {
 		return err
 	}
 
-	// if source defined then it can only deploy a single file, not folder
-	if len(files) > 1 && oc.shouldProcessOutput() {
-		return outputRequiresSingleFile
+	// outputs are keyed by deployment if path contains more than one deployment
+	aggregate := len(files) > 1
+
+	if len(oc.filter) > 0 {
+		if files, err = files.Filter(oc.filter); err != nil {
+			return err
+		}
+	}
+
+	var rawPath []string
+	if oc.raw != "" {
+		var file *loader.ParsedFile
+		if file, rawPath, err = findRawDeployment(files, oc.raw); err != nil {
+			return err
+		}
+
+		files = loader.ParsedFileCollection{file}
 	}
 
 	// Verify all modules have been initialized
@@ -126,12 +194,46 @@ func (oc *outputCmd) run(args []string) error {
 		}
 	}
 
-	if err := files.Walk(oc.runFile); err != nil {
+	if err := oc.walk(files, "output", false, oc.runFile); err != nil {
 		return err
 	}
 
 	ui.NewLine()
 
+	if !oc.shouldProcessOutput() {
+		return nil
+	}
+
+	if oc.raw != "" {
+		return oc.printRaw(oc.outputs[files[0].DeploymentName()], rawPath)
+	}
+
+	options := &output.Options{
+		ShowSensitive: oc.showSensitive,
+		GithubOutput:  os.Getenv("GITHUB_OUTPUT"),
+	}
+
+	var buf bytes.Buffer
+
+	if aggregate {
+		deployments := map[string]output.Outputs{}
+		for _, file := range files {
+			deployments[file.DeploymentName()] = oc.outputs[file.DeploymentName()]
+		}
+
+		err = output.WriteDeployments(&buf, oc.output, deployments, options)
+	} else {
+		err = output.Write(&buf, oc.output, oc.outputs[files[0].DeploymentName()], options)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if buf.Len() > 0 {
+		ui.Output("%s", strings.TrimSuffix(buf.String(), "\n"))
+	}
+
 	return nil
 }
 
@@ -150,15 +252,14 @@ func (oc *outputCmd) runFile(file *loader.ParsedFile) error {
 
 	// Resolving dependencies
 
-	if !paths.IsFile(file.VariableFile()) {
-		success, err := oc.resolveDependencies(file)
-		if err != nil {
-			return err
-		}
+	success, err := oc.ensureInputs(file, "output")
+	if err != nil {
+		return err
+	}
 
-		if !success {
-			return nil
-		}
+	if !success {
+		oc.setStatus(file, report.Skipped, dependenciesNotResolved)
+		return nil
 	}
 
 	// Executing terraform command
@@ -174,6 +275,7 @@ func (oc *outputCmd) runFile(file *loader.ParsedFile) error {
 		Stdout:           shell.Processors(outputProcessor),
 		Stderr:           shell.Processors(processors.NewUI(ui.Error)),
 		Env:              file.Env,
+		SensitiveStdout:  true,
 	}
 
 	if !oc.shouldProcessOutput() {
@@ -186,17 +288,28 @@ func (oc *outputCmd) runFile(file *loader.ParsedFile) error {
 		extraArgs = append(extraArgs, "-json")
 	}
 
-	if err := oc.Engine.Executor.Execute(options, "output", extraArgs...); err != nil {
+	if err := oc.Engine.Executor.Execute(file.Context(), options, "output", extraArgs...); err != nil {
 		return err
 	}
 
-	var values map[string]cty.Value
 	if oc.shouldProcessOutput() {
-		output, err := outputProcessor.GetOutput()
+		values, err := outputProcessor.GetOutput()
+		if err != nil {
+			return err
+		}
+
+		sensitive, err := outputProcessor.GetSensitive()
 		if err != nil {
 			return err
 		}
-		values = output
+
+		if err := file.SetOutputs(values); err != nil {
+			return err
+		}
+
+		oc.outputs[file.DeploymentName()] = output.NewOutputs(values, sensitive)
+	} else if err := oc.readOutputs(file, "output"); err != nil {
+		ui.Warn("Could not read outputs for hooks: %s", err)
 	}
 
 	paths.Remove(file.VariableFile())
@@ -209,75 +322,97 @@ func (oc *outputCmd) runFile(file *loader.ParsedFile) error {
 		return err
 	}
 
-	// Printing output
+	return nil
+}
 
-	ui.NewLine()
+// findRawDeployment returns the file that raw path references and the path to value in
+// its outputs. Deployment names can contain dots, so longest matching name is used.
+func findRawDeployment(files loader.ParsedFileCollection, raw string) (*loader.ParsedFile, []string, error) {
+	var found *loader.ParsedFile
 
-	switch oc.output {
-	case "json":
-		return printJSON(values)
-	case "yaml":
-		return printYAML(values)
-	case "env":
-		return printEnv(values)
-	default:
-		return nil
-	}
-}
+	for _, file := range files {
+		name := file.DeploymentName()
 
-func printJSON(values map[string]cty.Value) error {
-	obj := cty.ObjectVal(values)
-	bytes, err := ctyjson.Marshal(obj, obj.Type())
-	if err != nil {
-		return err
+		if !strings.HasPrefix(raw, name+".") {
+			continue
+		}
+
+		if found == nil || len(name) > len(found.DeploymentName()) {
+			found = file
+		}
 	}
 
-	ui.Output("%v", string(bytes))
+	if found == nil {
+		return nil, nil, rawPathIncorrect
+	}
 
-	return nil
+	return found, strings.Split(strings.TrimPrefix(raw, found.DeploymentName()+"."), "."), nil
 }
 
-func printYAML(values map[string]cty.Value) error {
-	obj := cty.ObjectVal(values)
-	bytes, err := ctyjson.Marshal(obj, obj.Type())
-	if err != nil {
-		return err
+// printRaw prints the value at path in outputs. Path can reference attributes in objects
+// and maps, and index in lists. Value has to be a string, number or bool.
+func (oc *outputCmd) printRaw(outputs output.Outputs, path []string) error {
+	out, ok := outputs[path[0]]
+	if !ok {
+		return errors.Errorf("%s: not found", path[0])
 	}
 
-	yamlBytes, err := yaml.JSONToYAML(bytes)
-	if err != nil {
-		return err
+	if out.Sensitive && !oc.showSensitive {
+		return rawValueSensitive
 	}
 
-	ui.Output("%v", string(yamlBytes))
+	value := out.Value
 
-	return nil
-}
+	for i, key := range path[1:] {
+		current := strings.Join(path[:i+2], ".")
 
-func printEnv(values map[string]cty.Value) error {
-	obj := cty.ObjectVal(values)
+		if value.IsNull() || !value.IsKnown() {
+			return errors.Errorf("%s: value is null", current)
+		}
 
-	for k, v := range flattenValue(obj, "TAU") {
-		ui.Output("%s=\"%s\"", k, v)
-	}
+		ty := value.Type()
 
-	return nil
-}
+		switch {
+		case ty.IsObjectType():
+			if !ty.HasAttribute(key) {
+				return errors.Errorf("%s: not found", current)
+			}
 
-func flattenValue(value cty.Value, prefix string) map[string]string {
-	values := map[string]string{}
+			value = value.GetAttr(key)
+		case ty.IsMapType():
+			if !value.HasIndex(cty.StringVal(key)).True() {
+				return errors.Errorf("%s: not found", current)
+			}
 
-	for k, v := range value.AsValueMap() {
-		if v.CanIterateElements() {
-			for fk, fv := range flattenValue(v, k) {
-				key := strings.ToUpper(fmt.Sprintf("%s_%s", prefix, fk))
-				values[key] = fv
+			value = value.Index(cty.StringVal(key))
+		case ty.IsListType() || ty.IsTupleType():
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= value.LengthInt() {
+				return errors.Errorf("%s: index out of range", current)
 			}
-		} else {
-			key := strings.ToUpper(fmt.Sprintf("%s_%s", prefix, k))
-			values[key] = v.AsString()
+
+			value = value.Index(cty.NumberIntVal(int64(idx)))
+		default:
+			return errors.Errorf("%s: value is not an object, map or list", current)
 		}
 	}
 
-	return values
+	current := strings.Join(path, ".")
+
+	if value.IsNull() || !value.IsKnown() {
+		return errors.Errorf("%s: value is null", current)
+	}
+
+	switch value.Type() {
+	case cty.String:
+		ui.Output("%s", value.AsString())
+	case cty.Number:
+		ui.Output("%s", value.AsBigFloat().Text('f', -1))
+	case cty.Bool:
+		ui.Output("%v", value.True())
+	default:
+		return errors.Errorf("%s: value is not a string, number or bool, use --output json", current)
+	}
+
+	return nil
 }
@@ -97,11 +97,17 @@ func newPtCmd(name string, command passThroughCommand) *cobra.Command {
 		SilenceUsage:          true,
 		Args:                  cobra.MaximumNArgs(command.MaximumNArgs),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := pt.meta.init(args); err != nil {
+			if err := pt.meta.init(cmd.Context(), args); err != nil {
 				return err
 			}
 
-			return pt.run(args)
+			unlock, err := pt.lock(cmd.Name())
+			if err != nil {
+				return err
+			}
+			defer unlock()
+
+			return pt.writeReport(pt.run(args))
 		},
 	}
 
@@ -114,6 +120,8 @@ func newPtCmd(name string, command passThroughCommand) *cobra.Command {
 	}
 
 	pt.addMetaFlags(ptCmd)
+	pt.addRefreshModulesFlag(ptCmd)
+	pt.addWalkFlags(ptCmd)
 
 	return ptCmd
 }
@@ -132,7 +140,7 @@ func (pt *ptCmd) run(args []string) error {
 		}
 	}
 
-	if err := files.Walk(func(file *loader.ParsedFile) error {
+	if err := pt.walk(files, pt.name, false, func(file *loader.ParsedFile) error {
 		return pt.runFile(file, args)
 	}); err != nil {
 		return err
@@ -174,7 +182,7 @@ func (pt *ptCmd) runFile(file *loader.ParsedFile, args []string) error {
 	extraArgs := getExtraArgs(pt.Engine.Compatibility.GetInvalidArgs(pt.name)...)
 	extraArgs = append(extraArgs, pt.command.AdditionalArgs...)
 	extraArgs = append(extraArgs, args...)
-	if err := pt.Engine.Executor.Execute(options, pt.name, extraArgs...); err != nil {
+	if err := pt.Engine.Executor.Execute(file.Context(), options, pt.name, extraArgs...); err != nil {
 		return err
 	}
 
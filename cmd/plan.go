@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"fmt"
-
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 
@@ -10,8 +8,7 @@ import (
 	"github.com/avinor/tau/pkg/config/loader"
 	"github.com/avinor/tau/pkg/helpers/paths"
 	"github.com/avinor/tau/pkg/helpers/ui"
-	"github.com/avinor/tau/pkg/shell"
-	"github.com/avinor/tau/pkg/shell/processors"
+	"github.com/avinor/tau/pkg/report"
 )
 
 type planCmd struct {
@@ -53,11 +50,17 @@ func newPlanCmd() *cobra.Command {
 		SilenceErrors:         true,
 		Args:                  cobra.MaximumNArgs(0),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if err := pc.meta.init(args); err != nil {
+			if err := pc.meta.init(cmd.Context(), args); err != nil {
 				return err
 			}
 
-			return pc.run(args)
+			unlock, err := pc.lock(cmd.Name())
+			if err != nil {
+				return err
+			}
+			defer unlock()
+
+			return pc.writeReport(pc.run(args))
 		},
 	}
 
@@ -65,6 +68,8 @@ func newPlanCmd() *cobra.Command {
 	f.BoolVar(&pc.destroy, "destroy", false, "create plan to destroy resources")
 
 	pc.addMetaFlags(planCmd)
+	pc.addRefreshModulesFlag(planCmd)
+	pc.addWalkFlags(planCmd)
 
 	return planCmd
 }
@@ -83,7 +88,7 @@ func (pc *planCmd) run(args []string) error {
 		}
 	}
 
-	if err := files.Walk(pc.runFile); err != nil {
+	if err := pc.walk(files, "plan", false, pc.runFile); err != nil {
 		return err
 	}
 
@@ -107,12 +112,13 @@ func (pc *planCmd) runFile(file *loader.ParsedFile) error {
 
 	// Resolving dependencies
 
-	success, err := pc.resolveDependencies(file)
+	success, err := pc.resolveDependencies(file, "plan")
 	if err != nil {
 		return err
 	}
 
 	if !success {
+		pc.setStatus(file, report.Skipped, dependenciesNotResolved)
 		return nil
 	}
 
@@ -124,25 +130,21 @@ func (pc *planCmd) runFile(file *loader.ParsedFile) error {
 
 	if !paths.IsFile(file.VariableFile()) {
 		ui.Warn("Cannot create a plan for %s", file.Name)
+		pc.setStatus(file, report.NoPlan, "no input variables file exists")
 		return nil
 	}
 
-	options := &shell.Options{
-		WorkingDirectory: file.ModuleDir(),
-		Stdout:           shell.Processors(processors.NewUI(ui.Info)),
-		Stderr:           shell.Processors(processors.NewUI(ui.Error)),
-		Env:              file.Env,
+	if err := pc.createPlan(file, file.ShouldDelete || pc.destroy); err != nil {
+		return err
 	}
 
-	extraArgs := getExtraArgs(pc.Engine.Compatibility.GetInvalidArgs("plan")...)
-	extraArgs = append(extraArgs, fmt.Sprintf("-out=%s", file.PlanFile()))
-
-	if file.ShouldDelete || pc.destroy {
-		extraArgs = append(extraArgs, "-destroy")
-	}
+	if pc.reportFile != "" {
+		summary, err := pc.planSummary(file)
+		if err != nil {
+			return err
+		}
 
-	if err := pc.Engine.Executor.Execute(options, "plan", extraArgs...); err != nil {
-		return err
+		pc.setPlanSummary(file, summary)
 	}
 
 	// Executing finish hook
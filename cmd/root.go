@@ -2,8 +2,10 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 	"strings"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
 	"github.com/avinor/tau/internal/templates"
@@ -19,8 +21,14 @@ var (
 		`)
 )
 
+var (
+	// logFormatIncorrect is returned if log format is not text or json
+	logFormatIncorrect = errors.Errorf("log format must be text or json")
+)
+
 var (
 	debug         bool
+	logFormat     string
 	workingDir    string
 	terraformArgs []string
 )
@@ -31,15 +39,28 @@ func NewRootCmd() *cobra.Command {
 		Use:   "tau",
 		Short: "Tau (Terraform Avinor Utility) manages terraform executions",
 		Long:  rootLong,
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			if debug {
 				ui.SetLevel(ui.DebugLevel)
 			}
+
+			switch logFormat {
+			case "text":
+			case "json":
+				ui.SetHandler(ui.NewJSONHandler(os.Stdin, os.Stdout, os.Stderr))
+			default:
+				return logFormatIncorrect
+			}
+
+			ui.WithContext(ui.CommandContext, cmd.Name())
+
+			return nil
 		},
 	}
 
 	p := rootCmd.PersistentFlags()
 	p.BoolVar(&debug, "debug", false, "enable verbose debug logs")
+	p.StringVar(&logFormat, "log-format", "text", "format of log messages, text or json")
 	p.StringVar(&workingDir, "working-directory", "", "working directory (default to current directory)")
 	p.StringArrayVarP(&terraformArgs, "args", "a", []string{}, "arguments to forward to terraform in key=value format")
 
@@ -48,7 +69,14 @@ func NewRootCmd() *cobra.Command {
 	rootCmd.AddCommand(newApplyCmd())
 	rootCmd.AddCommand(newDestroyCmd())
 	rootCmd.AddCommand(newOutputCmd())
+	rootCmd.AddCommand(newConsoleCmd())
+	rootCmd.AddCommand(newEvalCmd())
+	rootCmd.AddCommand(newInputsCmd())
+	rootCmd.AddCommand(newDocsCmd())
 	rootCmd.AddCommand(newFmtCmd())
+	rootCmd.AddCommand(newCleanCmd())
+	rootCmd.AddCommand(newUnlockCmd())
+	rootCmd.AddCommand(newLogsCmd())
 	rootCmd.AddCommand(newVersionCmd())
 
 	for name, cmd := range passThroughCommands {
@@ -0,0 +1,119 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/avinor/tau/internal/templates"
+	"github.com/avinor/tau/pkg/getter"
+	"github.com/avinor/tau/pkg/helpers/lock"
+	"github.com/avinor/tau/pkg/helpers/paths"
+	"github.com/avinor/tau/pkg/helpers/ui"
+)
+
+type unlockCmd struct {
+	files []string
+}
+
+var (
+	// unlockLong is long description of unlock command
+	unlockLong = templates.LongDesc(`Remove stale locks from tau directory. Tau locks the
+		temporary .tau directory, or the deployments processed, to prevent multiple tau
+		processes from changing the same files at the same time. Modules in the module cache
+		are also locked while they are downloaded. If a tau process is killed
+		the lock is not released and has to be removed with unlock. Only remove a lock if
+		no other tau process is running.
+
+		By default it removes all locks, use --file to only remove lock for a deployment.
+		`)
+
+	// unlockExample is examples for unlock command
+	unlockExample = templates.Examples(`
+		# Remove all locks
+		tau unlock
+
+		# Remove lock for a single deployment
+		tau unlock -f module.hcl
+	`)
+)
+
+// newUnlockCmd creates a new unlock command
+func newUnlockCmd() *cobra.Command {
+	uc := &unlockCmd{}
+
+	unlockCmd := &cobra.Command{
+		Use:                   "unlock [-f SOURCE]",
+		Short:                 "Remove stale locks from tau directory",
+		Long:                  unlockLong,
+		Example:               unlockExample,
+		DisableFlagsInUseLine: true,
+		SilenceUsage:          true,
+		SilenceErrors:         true,
+		Args:                  cobra.MaximumNArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return uc.run(args)
+		},
+	}
+
+	f := unlockCmd.Flags()
+	f.StringArrayVarP(&uc.files, "file", "f", []string{}, "only remove lock for deployment")
+
+	return unlockCmd
+}
+
+func (uc *unlockCmd) run(args []string) error {
+	if workingDir == "" {
+		workingDir = paths.WorkingDir
+	}
+
+	tauDir := paths.Join(workingDir, paths.TauPath)
+
+	locks := []string{}
+
+	if len(uc.files) == 0 {
+		matches, err := filepath.Glob(filepath.Join(tauDir, "*"+lock.Extension))
+		if err != nil {
+			return err
+		}
+
+		locks = matches
+
+		moduleLocks, err := filepath.Glob(filepath.Join(workingDir, paths.CachePath, getter.ModulesDirName, "*"+lock.Extension))
+		if err != nil {
+			return err
+		}
+		locks = append(locks, moduleLocks...)
+	}
+
+	for _, file := range uc.files {
+		locks = append(locks, filepath.Join(tauDir, filepath.Base(file)+lock.Extension))
+	}
+
+	removed := false
+
+	for _, path := range locks {
+		if !paths.IsFile(path) {
+			continue
+		}
+
+		if info, err := lock.Read(path); err == nil {
+			ui.Info("- Removing lock %s held by %s", filepath.Base(path), info)
+		} else {
+			ui.Info("- Removing lock %s", filepath.Base(path))
+		}
+
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+
+		removed = true
+	}
+
+	if !removed {
+		ui.Info("No locks found")
+	}
+
+	return nil
+}
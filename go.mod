@@ -7,7 +7,6 @@ require (
 	github.com/bgentry/speakeasy v0.1.0
 	github.com/fatih/color v1.7.0
 	github.com/ghodss/yaml v1.0.0
-	github.com/go-cmd/cmd v1.0.5
 	github.com/go-errors/errors v1.0.1
 	github.com/hashicorp/go-getter v1.4.2-0.20200106182914-9813cbd4eb02
 	github.com/hashicorp/hcl/v2 v2.5.1
@@ -18,6 +17,7 @@ require (
 	github.com/otiai10/curr v0.0.0-20190513014714-f5a3d24e5776 // indirect
 	github.com/pkg/errors v0.9.1
 	github.com/russross/blackfriday v1.5.2
+	github.com/spf13/afero v1.2.1
 	github.com/spf13/cobra v1.0.0
 	github.com/stretchr/testify v1.5.1
 	github.com/zclconf/go-cty v1.2.1
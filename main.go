@@ -4,17 +4,25 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 
 	"github.com/avinor/tau/cmd"
 	"github.com/avinor/tau/pkg/helpers/ui"
+	"github.com/avinor/tau/pkg/shell"
 )
 
 func main() {
 	log.SetOutput(&ui.Writer{})
 
-	if err := cmd.NewRootCmd().Execute(); err != nil {
+	// Interrupting tau forwards signal to running commands and waits for them to stop
+	ctx, stop := shell.NotifySignals(context.Background())
+
+	err := cmd.NewRootCmd().ExecuteContext(ctx)
+	stop()
+
+	if err != nil {
 		ui.NewLine()
 		ui.Fatal("Error: %s", err)
 		os.Exit(1)
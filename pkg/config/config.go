@@ -21,6 +21,7 @@ type Config struct {
 	Backend      *Backend      `hcl:"backend,block"`
 	Module       *Module       `hcl:"module,block"`
 	Inputs       *Inputs       `hcl:"inputs,block"`
+	Retry        *Retry        `hcl:"retry,block"`
 }
 
 // Merge all sources into current configuration struct.
@@ -54,6 +55,10 @@ func (c *Config) Merge(srcs []*Config) error {
 		return err
 	}
 
+	if err := mergeRetries(c, srcs); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -96,5 +101,11 @@ func (c Config) Validate() (bool, error) {
 		}
 	}
 
+	if c.Retry != nil {
+		if valid, err := c.Retry.Validate(); !valid {
+			return false, err
+		}
+	}
+
 	return true, nil
 }
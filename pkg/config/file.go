@@ -8,6 +8,7 @@ import (
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/zclconf/go-cty/cty"
 
 	hclcontext "github.com/avinor/tau/pkg/helpers/hcl"
@@ -56,6 +57,11 @@ func (f *File) AddChild(file *File) {
 	f.children = append(f.children, file)
 }
 
+// Children returns all child files added to file
+func (f *File) Children() []*File {
+	return f.children
+}
+
 // AddToContext adds a variable to the evaluation context of this file
 func (f *File) AddToContext(key string, value cty.Value) {
 	f.context.Variables[key] = value
@@ -118,6 +124,10 @@ func (f *File) parse(context *hcl.EvalContext) (*Config, error) {
 		return nil, bodyDiags
 	}
 
+	if body, ok := hclFile.Body.(*hclsyntax.Body); ok {
+		setHookDefRanges(config.Hooks, body)
+	}
+
 	return config, nil
 }
 
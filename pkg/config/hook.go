@@ -1,23 +1,69 @@
 package config
 
 import (
+	"regexp"
 	"strings"
+	"time"
 
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/pkg/errors"
 )
 
 var (
 	// ValidHookTriggers is a list of valid values for trigger_on
-	ValidHookTriggers = []string{"prepare", "finish"}
+	ValidHookTriggers = []string{"prepare", "finish", "error", "always", "pre_resolve", "post_resolve"}
 
-	// scriptOrCommandIsRequired is returned if command or script is not set
-	scriptOrCommandIsRequired = errors.Errorf("hook command or script is required")
+	// ValidHookOutputFormats is a list of valid values for output_format
+	ValidHookOutputFormats = []string{"keyvalue", "json", "dotenv", "export"}
+
+	// scriptOrCommandIsRequired is returned if command, script or inline is not set
+	scriptOrCommandIsRequired = errors.Errorf("hook command, script or inline is required")
 
 	// scriptAndCommandBothDefined is returned if both command and script attribute is defined
 	scriptAndCommandBothDefined = errors.Errorf("script and command can not be both defined in hook")
 
+	// inlineAndCommandOrScriptDefined is returned if inline is defined together with command or script
+	inlineAndCommandOrScriptDefined = errors.Errorf("inline can not be defined together with command or script in hook")
+
+	// interpreterRequiresInline is returned if interpreter is defined without inline
+	interpreterRequiresInline = errors.Errorf("interpreter can only be used with inline")
+
 	// triggerOnValueIncorrect is returned if the trigger_on value is incorrect value
 	triggerOnValueIncorrect = errors.Errorf("trigger_on has to be one of: %s", strings.Join(ValidHookTriggers, ", "))
+
+	// outputFormatValueIncorrect is returned if the output_format value is incorrect value
+	outputFormatValueIncorrect = errors.Errorf("output_format has to be one of: %s", strings.Join(ValidHookOutputFormats, ", "))
+
+	// scriptSHA256Incorrect is returned if script_sha256 is not a valid sha256 checksum
+	scriptSHA256Incorrect = errors.Errorf("script_sha256 has to be a hex encoded sha256 checksum")
+
+	// scriptIntegrityRequiresScript is returned if integrity attributes are set without script
+	scriptIntegrityRequiresScript = errors.Errorf("script_sha256, script_signature and script_public_key can only be used with script")
+
+	// scriptSignatureRequiresKey is returned if only one of signature and public key is defined
+	scriptSignatureRequiresKey = errors.Errorf("script_signature and script_public_key have to be defined together")
+
+	// sha256Regexp matches a hex encoded sha256 checksum
+	sha256Regexp = regexp.MustCompile("^[a-fA-F0-9]{64}$")
+
+	// cacheTTLValueIncorrect is returned if cache_ttl is not a valid duration
+	cacheTTLValueIncorrect = errors.Errorf("cache_ttl has to be a valid duration, for instance 30m")
+
+	// timeoutValueIncorrect is returned if timeout is not a valid duration
+	timeoutValueIncorrect = errors.Errorf("timeout has to be a valid duration, for instance 5m")
+
+	// retriesValueIncorrect is returned if retries is negative
+	retriesValueIncorrect = errors.Errorf("retries cannot be a negative number")
+
+	// retryBackoffValueIncorrect is returned if retry_backoff is not a valid duration
+	retryBackoffValueIncorrect = errors.Errorf("retry_backoff has to be a valid duration, for instance 10s")
+
+	// DefaultHookRetryBackoff is the time to wait before first retry if retry_backoff is not set
+	DefaultHookRetryBackoff = 5 * time.Second
+
+	// outputFormatPathNotSupported is returned if a path is defined for other formats than json
+	outputFormatPathNotSupported = errors.Errorf("output_format only supports a path for json format")
 )
 
 // Hook describes a hook that should be run at specific time during deployment.
@@ -26,13 +72,33 @@ var (
 // TriggerOn decides at which event this hook should trigger. On event command specified
 // in Command will run. If read_output is set to true it will try to parse the output
 // from command (stdout) as key=value pairs and add them to list of environment
-// variables that are sent to terraform commands
+// variables that are sent to terraform commands. OutputFormat decides how output is parsed,
+// default is keyvalue. For json format a path can be added after colon (json:.data.keys)
+// to read the variables from a nested object.
 //
 // To prevent same command from running multiple times it will assume that running same command
 // multiple times always produce same result and therefore cache output. To prevent this
 // set disable_cache = true. It will force the command to run for every source including hook
 //
+// Scripts are downloaded before executing them. To verify the integrity of the script set
+// script_sha256 to the expected checksum, and/or script_signature and script_public_key to
+// verify an ed25519 signature of the script. Script is verified every time before it runs.
+//
+// Inline can be used instead of command or script to define the script in config. It is written
+// to cache directory and executed with Interpreter, default is sh.
+//
+// Cache only lasts for one execution of tau. Set cache_ttl to a duration (30m) to store the output
+// encrypted in cache directory and reuse it in later executions until it expires.
+//
 // By default it will fail command if hook fails. To prevent this set fail_on_error = false
+//
+// Timeout stops the hook if it has not completed within the duration. If Retries is set
+// a failing hook is retried, waiting RetryBackoff before first retry, doubling it for every
+// following retry. A hook is only considered failed once all retries have failed.
+//
+// Hooks run in the order they are declared, hooks from auto imported files first. DependsOn
+// can be used to make sure a hook runs after other hooks, for instance if it needs environment
+// variables set by another hook.
 type Hook struct {
 	Type         string    `hcl:"type,label"`
 	TriggerOn    *string   `hcl:"trigger_on,attr"`
@@ -43,6 +109,24 @@ type Hook struct {
 	FailOnError  *bool     `hcl:"fail_on_error,attr"`
 	DisableCache *bool     `hcl:"disable_cache,attr"`
 	WorkingDir   *string   `hcl:"working_dir,attr"`
+	OutputFormat *string   `hcl:"output_format,attr"`
+	DependsOn    *[]string `hcl:"depends_on,attr"`
+	CacheTTL     *string   `hcl:"cache_ttl,attr"`
+
+	ScriptSHA256    *string `hcl:"script_sha256,attr"`
+	ScriptSignature *string `hcl:"script_signature,attr"`
+	ScriptPublicKey *string `hcl:"script_public_key,attr"`
+
+	Inline      *string   `hcl:"inline,attr"`
+	Interpreter *[]string `hcl:"interpreter,attr"`
+
+	Timeout      *string `hcl:"timeout,attr"`
+	Retries      *int    `hcl:"retries,attr"`
+	RetryBackoff *string `hcl:"retry_backoff,attr"`
+
+	// DefRange is the location where hook is defined. Not set by decoder, but added
+	// after parsing file so errors can be reported against source location
+	DefRange hcl.Range
 }
 
 // Merge current hook with config from source
@@ -60,6 +144,15 @@ func (h *Hook) Merge(src *Hook) error {
 	h.Command = setFirstStringPointer(src.Command, h.Command)
 	h.Script = setFirstStringPointer(src.Script, h.Script)
 	h.WorkingDir = setFirstStringPointer(src.WorkingDir, h.WorkingDir)
+	h.OutputFormat = setFirstStringPointer(src.OutputFormat, h.OutputFormat)
+	h.CacheTTL = setFirstStringPointer(src.CacheTTL, h.CacheTTL)
+	h.ScriptSHA256 = setFirstStringPointer(src.ScriptSHA256, h.ScriptSHA256)
+	h.ScriptSignature = setFirstStringPointer(src.ScriptSignature, h.ScriptSignature)
+	h.ScriptPublicKey = setFirstStringPointer(src.ScriptPublicKey, h.ScriptPublicKey)
+	h.Inline = setFirstStringPointer(src.Inline, h.Inline)
+	h.Timeout = setFirstStringPointer(src.Timeout, h.Timeout)
+	h.RetryBackoff = setFirstStringPointer(src.RetryBackoff, h.RetryBackoff)
+	h.Retries = setFirstIntPointer(src.Retries, h.Retries)
 	h.SetEnv = setFirstBoolPointer(src.SetEnv, h.SetEnv)
 	h.FailOnError = setFirstBoolPointer(src.FailOnError, h.FailOnError)
 	h.DisableCache = setFirstBoolPointer(src.DisableCache, h.DisableCache)
@@ -74,13 +167,33 @@ func (h *Hook) Merge(src *Hook) error {
 		}
 	}
 
+	if src.Interpreter != nil {
+		h.Interpreter = src.Interpreter
+	}
+
+	if src.DependsOn != nil {
+		if h.DependsOn == nil {
+			h.DependsOn = src.DependsOn
+		} else {
+			for _, dep := range *src.DependsOn {
+				if !h.DependsOnHook(dep) {
+					*h.DependsOn = append(*h.DependsOn, dep)
+				}
+			}
+		}
+	}
+
+	if src.DefRange.Filename != "" {
+		h.DefRange = src.DefRange
+	}
+
 	return nil
 }
 
 // Validate that all required settings are correct
 func (h Hook) Validate() (bool, error) {
 	if !h.HasCommand() {
-		if !h.HasScript() {
+		if !h.HasScript() && !h.HasInline() {
 			return false, scriptOrCommandIsRequired
 		}
 	}
@@ -89,6 +202,14 @@ func (h Hook) Validate() (bool, error) {
 		return false, scriptAndCommandBothDefined
 	}
 
+	if h.HasInline() && (h.HasCommand() || h.HasScript()) {
+		return false, inlineAndCommandOrScriptDefined
+	}
+
+	if h.Interpreter != nil && !h.HasInline() {
+		return false, interpreterRequiresInline
+	}
+
 	if h.TriggerOn == nil {
 		return false, triggerOnValueIncorrect
 	}
@@ -105,19 +226,186 @@ func (h Hook) Validate() (bool, error) {
 		return false, triggerOnValueIncorrect
 	}
 
+	if h.OutputFormat != nil {
+		format, path := h.GetOutputFormat()
+
+		validFormat := false
+		for _, valid := range ValidHookOutputFormats {
+			if valid == format {
+				validFormat = true
+			}
+		}
+
+		if !validFormat {
+			return false, outputFormatValueIncorrect
+		}
+
+		if path != "" && format != "json" {
+			return false, outputFormatPathNotSupported
+		}
+	}
+
+	if h.HasIntegrityCheck() && !h.HasScript() {
+		return false, scriptIntegrityRequiresScript
+	}
+
+	if h.ScriptSHA256 != nil && !sha256Regexp.MatchString(*h.ScriptSHA256) {
+		return false, scriptSHA256Incorrect
+	}
+
+	if (h.ScriptSignature == nil) != (h.ScriptPublicKey == nil) {
+		return false, scriptSignatureRequiresKey
+	}
+
+	if h.CacheTTL != nil {
+		if ttl, err := time.ParseDuration(*h.CacheTTL); err != nil || ttl <= 0 {
+			return false, cacheTTLValueIncorrect
+		}
+	}
+
+	if h.Timeout != nil {
+		if timeout, err := time.ParseDuration(*h.Timeout); err != nil || timeout <= 0 {
+			return false, timeoutValueIncorrect
+		}
+	}
+
+	if h.Retries != nil && *h.Retries < 0 {
+		return false, retriesValueIncorrect
+	}
+
+	if h.RetryBackoff != nil {
+		if backoff, err := time.ParseDuration(*h.RetryBackoff); err != nil || backoff < 0 {
+			return false, retryBackoffValueIncorrect
+		}
+	}
+
 	return true, nil
 }
 
+// GetCacheTTL returns how long output should be cached between executions. Returns 0 if
+// output should not be cached, or cache is disabled.
+func (h Hook) GetCacheTTL() time.Duration {
+	if h.CacheTTL == nil || (h.DisableCache != nil && *h.DisableCache) {
+		return 0
+	}
+
+	ttl, err := time.ParseDuration(*h.CacheTTL)
+	if err != nil {
+		return 0
+	}
+
+	return ttl
+}
+
+// GetTimeout returns the maximum duration hook can run. Returns 0 if there is no timeout.
+func (h Hook) GetTimeout() time.Duration {
+	if h.Timeout == nil {
+		return 0
+	}
+
+	timeout, err := time.ParseDuration(*h.Timeout)
+	if err != nil {
+		return 0
+	}
+
+	return timeout
+}
+
+// GetRetries returns number of times to retry hook if it fails
+func (h Hook) GetRetries() int {
+	if h.Retries == nil || *h.Retries < 0 {
+		return 0
+	}
+
+	return *h.Retries
+}
+
+// GetRetryBackoff returns the duration to wait before first retry. Returns
+// DefaultHookRetryBackoff if retry_backoff is not set.
+func (h Hook) GetRetryBackoff() time.Duration {
+	if h.RetryBackoff == nil {
+		return DefaultHookRetryBackoff
+	}
+
+	backoff, err := time.ParseDuration(*h.RetryBackoff)
+	if err != nil {
+		return DefaultHookRetryBackoff
+	}
+
+	return backoff
+}
+
+// GetOutputFormat returns the format output should be parsed as and an optional path
+// to read values from. If output_format is not set it returns keyvalue format.
+func (h Hook) GetOutputFormat() (string, string) {
+	if h.OutputFormat == nil || *h.OutputFormat == "" {
+		return "keyvalue", ""
+	}
+
+	split := strings.SplitN(*h.OutputFormat, ":", 2)
+	format := strings.ToLower(strings.TrimSpace(split[0]))
+
+	if len(split) > 1 {
+		return format, strings.TrimSpace(split[1])
+	}
+
+	return format, ""
+}
+
 // HasScript returns true if script is defined
 func (h Hook) HasScript() bool {
 	return h.Script != nil && *h.Script != ""
 }
 
+// HasInline returns true if inline script is defined
+func (h Hook) HasInline() bool {
+	return h.Inline != nil && *h.Inline != ""
+}
+
+// HasIntegrityCheck returns true if script should be verified before executing
+func (h Hook) HasIntegrityCheck() bool {
+	return h.ScriptSHA256 != nil || h.ScriptSignature != nil || h.ScriptPublicKey != nil
+}
+
 // HasCommand returns true is command is defined
 func (h Hook) HasCommand() bool {
 	return h.Command != nil && *h.Command != ""
 }
 
+// DependsOnHook returns true if hook depends on hook with name
+func (h Hook) DependsOnHook(name string) bool {
+	if h.DependsOn == nil {
+		return false
+	}
+
+	for _, dep := range *h.DependsOn {
+		if dep == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setHookDefRanges sets the DefRange on hooks from the hook blocks in body. Hooks are decoded
+// in same order as they are defined in body, so they can be matched on index.
+func setHookDefRanges(hooks []*Hook, body *hclsyntax.Body) {
+	idx := 0
+
+	for _, block := range body.Blocks {
+		if block.Type != "hook" {
+			continue
+		}
+
+		if idx >= len(hooks) {
+			return
+		}
+
+		hooks[idx].DefRange = block.DefRange()
+		idx++
+	}
+}
+
 // setFirstStringPointer returns first string that is not empty
 func setFirstStringPointer(args ...*string) *string {
 	for _, arg := range args {
@@ -140,14 +428,28 @@ func setFirstBoolPointer(args ...*bool) *bool {
 	return nil
 }
 
-// mergeHooks merges the hooks arrays into destination config.
+// setFirstIntPointer returns first int pointer that has a reference
+func setFirstIntPointer(args ...*int) *int {
+	for _, arg := range args {
+		if arg != nil {
+			return arg
+		}
+	}
+
+	return nil
+}
+
+// mergeHooks merges the hooks arrays into destination config. Hooks keep the order they are
+// first declared in, but are sorted so hooks always come after the hooks they depend on.
 func mergeHooks(dest *Config, srcs []*Config) error {
 	hooks := map[string]*Hook{}
+	ordered := []*Hook{}
 
 	for _, src := range srcs {
 		for _, hook := range src.Hooks {
 			if _, ok := hooks[hook.Type]; !ok {
 				hooks[hook.Type] = hook
+				ordered = append(ordered, hook)
 				continue
 			}
 
@@ -157,9 +459,66 @@ func mergeHooks(dest *Config, srcs []*Config) error {
 		}
 	}
 
-	for _, hook := range hooks {
-		dest.Hooks = append(dest.Hooks, hook)
+	sorted, err := sortHooks(ordered)
+	if err != nil {
+		return err
 	}
 
+	dest.Hooks = append(dest.Hooks, sorted...)
+
 	return nil
 }
+
+// sortHooks sorts the hooks so any hook comes after the hooks it depends on. Otherwise it
+// keeps the order of hooks. Returns an error if depending on unknown hook or if there is a
+// circular dependency between hooks.
+func sortHooks(hooks []*Hook) ([]*Hook, error) {
+	index := map[string]*Hook{}
+	for _, hook := range hooks {
+		index[hook.Type] = hook
+	}
+
+	sorted := []*Hook{}
+	visited := map[string]bool{}
+	visiting := map[string]bool{}
+
+	var visit func(hook *Hook) error
+	visit = func(hook *Hook) error {
+		if visited[hook.Type] {
+			return nil
+		}
+
+		if visiting[hook.Type] {
+			return errors.Errorf("circular dependency found for hook %s", hook.Type)
+		}
+
+		visiting[hook.Type] = true
+
+		if hook.DependsOn != nil {
+			for _, dep := range *hook.DependsOn {
+				depHook, ok := index[dep]
+				if !ok {
+					return errors.Errorf("hook %s depends on unknown hook %s", hook.Type, dep)
+				}
+
+				if err := visit(depHook); err != nil {
+					return err
+				}
+			}
+		}
+
+		visiting[hook.Type] = false
+		visited[hook.Type] = true
+		sorted = append(sorted, hook)
+
+		return nil
+	}
+
+	for _, hook := range hooks {
+		if err := visit(hook); err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"testing"
 
+	"github.com/hashicorp/hcl/v2"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/avinor/tau/pkg/helpers/strings"
@@ -58,16 +59,219 @@ const (
 			trigger_on = "prepare:init"
 		}
 	`
+
+	hookErrorTrigger = `
+		hook "name" {
+			command = "notify"
+			trigger_on = "error:apply,plan"
+		}
+	`
+
+	hookCacheTTL = `
+		hook "name" {
+			command = "command"
+			trigger_on = "prepare"
+			cache_ttl = "30m"
+		}
+	`
+
+	hookInvalidCacheTTL = `
+		hook "name" {
+			command = "command"
+			trigger_on = "prepare"
+			cache_ttl = "thirty minutes"
+		}
+	`
+
+	hookScriptSHA256 = `
+		hook "name" {
+			script = "https://example.com/script.sh"
+			trigger_on = "prepare"
+			script_sha256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+		}
+	`
+
+	hookInvalidScriptSHA256 = `
+		hook "name" {
+			script = "https://example.com/script.sh"
+			trigger_on = "prepare"
+			script_sha256 = "abc"
+		}
+	`
+
+	hookCommandSHA256 = `
+		hook "name" {
+			command = "command"
+			trigger_on = "prepare"
+			script_sha256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+		}
+	`
+
+	hookSignatureWithoutKey = `
+		hook "name" {
+			script = "https://example.com/script.sh"
+			trigger_on = "prepare"
+			script_signature = "c2lnbmF0dXJl"
+		}
+	`
+
+	hookInline = `
+		hook "name" {
+			trigger_on = "prepare"
+			interpreter = ["bash", "-euo", "pipefail"]
+			inline = <<EOT
+echo "key = value"
+EOT
+		}
+	`
+
+	hookInlineAndCommand = `
+		hook "name" {
+			command = "command"
+			trigger_on = "prepare"
+			inline = "echo hello"
+		}
+	`
+
+	hookInterpreterWithoutInline = `
+		hook "name" {
+			command = "command"
+			trigger_on = "prepare"
+			interpreter = ["bash"]
+		}
+	`
+
+	hookTimeout = `
+		hook "name" {
+			command = "command"
+			trigger_on = "prepare"
+			timeout = "5m"
+			retries = 3
+			retry_backoff = "10s"
+		}
+	`
+
+	hookInvalidTimeout = `
+		hook "name" {
+			command = "command"
+			trigger_on = "prepare"
+			timeout = "forever"
+		}
+	`
+
+	hookNegativeRetries = `
+		hook "name" {
+			command = "command"
+			trigger_on = "prepare"
+			retries = -1
+		}
+	`
+
+	hookTest8 = `
+		hook "name" {
+			command = "command"
+			trigger_on = "prepare"
+			output_format = "json:.data.keys"
+		}
+	`
+
+	hookTest9 = `
+		hook "name" {
+			command = "command"
+			trigger_on = "prepare"
+			output_format = "yaml"
+		}
+	`
+
+	hookOrderAuto = `
+		hook "first" {
+			command = "first"
+			trigger_on = "prepare"
+		}
+
+		hook "second" {
+			command = "second"
+			trigger_on = "prepare"
+		}
+	`
+
+	hookOrderSource = `
+		hook "access_key" {
+			command = "access_key"
+			trigger_on = "prepare"
+			depends_on = ["token"]
+		}
+
+		hook "token" {
+			command = "token"
+			trigger_on = "prepare"
+		}
+
+		hook "first" {
+			depends_on = ["second"]
+		}
+	`
+
+	hookUnknownDep = `
+		hook "name" {
+			command = "command"
+			trigger_on = "prepare"
+			depends_on = ["unknown"]
+		}
+	`
+
+	hookCircularDep = `
+		hook "a" {
+			command = "command"
+			trigger_on = "prepare"
+			depends_on = ["b"]
+		}
+
+		hook "b" {
+			command = "command"
+			trigger_on = "prepare"
+			depends_on = ["a"]
+		}
+	`
+
+	hookTest10 = `
+		hook "name" {
+			command = "command"
+			trigger_on = "prepare"
+			output_format = "dotenv:.data"
+		}
+	`
 )
 
 var (
-	hookFile1, _ = NewFile("/hook1", []byte(hookTest1))
-	hookFile2, _ = NewFile("/hook2", []byte(hookTest2))
-	hookFile3, _ = NewFile("/hook3", []byte(hookTest3))
-	hookFile4, _ = NewFile("/hook4", []byte(hookTest4))
-	hookFile5, _ = NewFile("/hook5", []byte(hookTest5))
-	hookFile6, _ = NewFile("/hook6", []byte(hookTest6))
-	hookFile7, _ = NewFile("/hook7", []byte(hookTest7))
+	hookFile1, _                        = NewFile("/hook1", []byte(hookTest1))
+	hookFile2, _                        = NewFile("/hook2", []byte(hookTest2))
+	hookFile3, _                        = NewFile("/hook3", []byte(hookTest3))
+	hookFile4, _                        = NewFile("/hook4", []byte(hookTest4))
+	hookFile5, _                        = NewFile("/hook5", []byte(hookTest5))
+	hookFile6, _                        = NewFile("/hook6", []byte(hookTest6))
+	hookFile7, _                        = NewFile("/hook7", []byte(hookTest7))
+	hookFile8, _                        = NewFile("/hook8", []byte(hookTest8))
+	hookErrorTriggerFile, _             = NewFile("/hook_error", []byte(hookErrorTrigger))
+	hookCacheTTLFile, _                 = NewFile("/hook_cache_ttl", []byte(hookCacheTTL))
+	hookInvalidCacheTTLFile, _          = NewFile("/hook_invalid_cache_ttl", []byte(hookInvalidCacheTTL))
+	hookScriptSHA256File, _             = NewFile("/hook_script_sha256", []byte(hookScriptSHA256))
+	hookInvalidScriptSHA256File, _      = NewFile("/hook_invalid_script_sha256", []byte(hookInvalidScriptSHA256))
+	hookCommandSHA256File, _            = NewFile("/hook_command_sha256", []byte(hookCommandSHA256))
+	hookSignatureWithoutKeyFile, _      = NewFile("/hook_signature_without_key", []byte(hookSignatureWithoutKey))
+	hookInlineFile, _                   = NewFile("/hook_inline", []byte(hookInline))
+	hookInlineAndCommandFile, _         = NewFile("/hook_inline_and_command", []byte(hookInlineAndCommand))
+	hookInterpreterWithoutInlineFile, _ = NewFile("/hook_interpreter_without_inline", []byte(hookInterpreterWithoutInline))
+	hookTimeoutFile, _                  = NewFile("/hook_timeout", []byte(hookTimeout))
+	hookInvalidTimeoutFile, _           = NewFile("/hook_invalid_timeout", []byte(hookInvalidTimeout))
+	hookNegativeRetriesFile, _          = NewFile("/hook_negative_retries", []byte(hookNegativeRetries))
+	hookFile9, _                        = NewFile("/hook9", []byte(hookTest9))
+	hookFile10, _                       = NewFile("/hook10", []byte(hookTest10))
+
+	hookOrderAutoFile, _   = NewFile("/order_auto", []byte(hookOrderAuto))
+	hookOrderSourceFile, _ = NewFile("/order", []byte(hookOrderSource))
+	hookUnknownDepFile, _  = NewFile("/unknown_dep", []byte(hookUnknownDep))
+	hookCircularDepFile, _ = NewFile("/circular_dep", []byte(hookCircularDep))
 )
 
 func TestHookMerge(t *testing.T) {
@@ -128,6 +332,11 @@ func TestHookMerge(t *testing.T) {
 				actual[dep.Name] = dep.Source
 			}
 
+			// DefRange is tested in TestHookDefRange, clear it to only compare attributes
+			for _, hook := range config.Hooks {
+				hook.DefRange = hcl.Range{}
+			}
+
 			assert.ElementsMatch(t, test.Expected, config.Hooks)
 		})
 	}
@@ -168,6 +377,102 @@ func TestHookValidation(t *testing.T) {
 				"name": {Result: false, Error: scriptAndCommandBothDefined},
 			},
 		},
+		{
+			[]*File{hookErrorTriggerFile},
+			map[string]ValidationResult{
+				"name": {Result: true, Error: nil},
+			},
+		},
+		{
+			[]*File{hookCacheTTLFile},
+			map[string]ValidationResult{
+				"name": {Result: true, Error: nil},
+			},
+		},
+		{
+			[]*File{hookInvalidCacheTTLFile},
+			map[string]ValidationResult{
+				"name": {Result: false, Error: cacheTTLValueIncorrect},
+			},
+		},
+		{
+			[]*File{hookInlineFile},
+			map[string]ValidationResult{
+				"name": {Result: true, Error: nil},
+			},
+		},
+		{
+			[]*File{hookInlineAndCommandFile},
+			map[string]ValidationResult{
+				"name": {Result: false, Error: inlineAndCommandOrScriptDefined},
+			},
+		},
+		{
+			[]*File{hookInterpreterWithoutInlineFile},
+			map[string]ValidationResult{
+				"name": {Result: false, Error: interpreterRequiresInline},
+			},
+		},
+		{
+			[]*File{hookTimeoutFile},
+			map[string]ValidationResult{
+				"name": {Result: true, Error: nil},
+			},
+		},
+		{
+			[]*File{hookInvalidTimeoutFile},
+			map[string]ValidationResult{
+				"name": {Result: false, Error: timeoutValueIncorrect},
+			},
+		},
+		{
+			[]*File{hookNegativeRetriesFile},
+			map[string]ValidationResult{
+				"name": {Result: false, Error: retriesValueIncorrect},
+			},
+		},
+		{
+			[]*File{hookScriptSHA256File},
+			map[string]ValidationResult{
+				"name": {Result: true, Error: nil},
+			},
+		},
+		{
+			[]*File{hookInvalidScriptSHA256File},
+			map[string]ValidationResult{
+				"name": {Result: false, Error: scriptSHA256Incorrect},
+			},
+		},
+		{
+			[]*File{hookCommandSHA256File},
+			map[string]ValidationResult{
+				"name": {Result: false, Error: scriptIntegrityRequiresScript},
+			},
+		},
+		{
+			[]*File{hookSignatureWithoutKeyFile},
+			map[string]ValidationResult{
+				"name": {Result: false, Error: scriptSignatureRequiresKey},
+			},
+		},
+		{
+			[]*File{hookFile8},
+			map[string]ValidationResult{
+				"name": {Result: true, Error: nil},
+			},
+		},
+		{
+			[]*File{hookFile9},
+			map[string]ValidationResult{
+				"name": {Result: false, Error: outputFormatValueIncorrect},
+			},
+		},
+		{
+			[]*File{hookFile10},
+			map[string]ValidationResult{
+				"name": {Result: false, Error: outputFormatPathNotSupported},
+			},
+		},
 	}
 
 	for i, test := range tests {
@@ -190,3 +495,71 @@ func TestHookValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestHookDefRange(t *testing.T) {
+	config := &Config{}
+	err := mergeHooks(config, getConfigFromFiles(t, []*File{hookFile1, hookFile2}))
+	assert.NoError(t, err)
+
+	if assert.Len(t, config.Hooks, 1) {
+		assert.Equal(t, "/hook2", config.Hooks[0].DefRange.Filename)
+		assert.Equal(t, 2, config.Hooks[0].DefRange.Start.Line)
+	}
+}
+
+func TestHookOrder(t *testing.T) {
+	tests := []struct {
+		Files    []*File
+		Expected []string
+		Error    string
+	}{
+		{
+			[]*File{hookOrderAutoFile},
+			[]string{"first", "second"},
+			"",
+		},
+		{
+			[]*File{hookOrderSourceFile},
+			nil,
+			"hook first depends on unknown hook second",
+		},
+		{
+			[]*File{hookOrderAutoFile, hookOrderSourceFile},
+			[]string{"second", "first", "token", "access_key"},
+			"",
+		},
+		{
+			[]*File{hookUnknownDepFile},
+			nil,
+			"hook name depends on unknown hook unknown",
+		},
+		{
+			[]*File{hookCircularDepFile},
+			nil,
+			"circular dependency found for hook a",
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			// run multiple times to make sure order is not random
+			for run := 0; run < 10; run++ {
+				config := &Config{}
+				err := mergeHooks(config, getConfigFromFiles(t, test.Files))
+
+				if test.Error != "" {
+					assert.EqualError(t, err, test.Error)
+					return
+				}
+
+				actual := []string{}
+				for _, hook := range config.Hooks {
+					actual = append(actual, hook.Type)
+				}
+
+				assert.NoError(t, err)
+				assert.Equal(t, test.Expected, actual)
+			}
+		})
+	}
+}
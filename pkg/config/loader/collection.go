@@ -12,6 +12,9 @@ var (
 	// moduleNotInitError is returned when a module is not initialized
 	moduleNotInitError = errors.Errorf("module is not initialized")
 
+	// walkStopped is returned for files not processed because walk stopped after an error
+	walkStopped = errors.Errorf("walk stopped after previous error")
+
 	lock = sync.Mutex{}
 )
 
@@ -33,10 +36,89 @@ func (c ParsedFileCollection) IsAllInitialized() error {
 	return nil
 }
 
+// Filter returns the files with deployment name, or file name, in names. Order of files
+// in collection is kept. Returns an error if a name does not match any file.
+func (c ParsedFileCollection) Filter(names []string) (ParsedFileCollection, error) {
+	filtered := ParsedFileCollection{}
+
+	for _, name := range names {
+		if c.find(name) == nil {
+			return nil, errors.Errorf("no deployment named %s", name)
+		}
+	}
+
+	for _, file := range c {
+		for _, name := range names {
+			if file.DeploymentName() == name || file.Name == name {
+				filtered = append(filtered, file)
+				break
+			}
+		}
+	}
+
+	return filtered, nil
+}
+
+// find returns file with deployment name, or file name, equal to name, or nil if none
+func (c ParsedFileCollection) find(name string) *ParsedFile {
+	for _, file := range c {
+		if file.DeploymentName() == name || file.Name == name {
+			return file
+		}
+	}
+
+	return nil
+}
+
+// WalkOptions controls how the collection is walked
+type WalkOptions struct {
+	// KeepGoing continues processing files that do not depend on a failed file. Without
+	// it no more files are processed after first failure.
+	KeepGoing bool
+
+	// Reverse processes files before their dependencies, for instance when destroying
+	Reverse bool
+}
+
+// WalkResult is the result of walking the collection. Files are in same order as in
+// collection. Skipped are the files that were not processed because a file they depend
+// on failed, while Stopped are files not processed because walk stopped after first failure.
+type WalkResult struct {
+	Succeeded []*ParsedFile
+	Failed    []*ParsedFile
+	Skipped   []*ParsedFile
+	Stopped   []*ParsedFile
+	Errors    map[*ParsedFile]error
+}
+
+// Err returns the error if one file failed, or an error with number of files that failed
+// if more than one failed. Returns nil if all files succeeded
+func (r *WalkResult) Err() error {
+	switch len(r.Failed) {
+	case 0:
+		return nil
+	case 1:
+		return r.Errors[r.Failed[0]]
+	default:
+		return errors.Errorf("%v deployments failed", len(r.Failed))
+	}
+}
+
 // Walk travers the files in collection and execute them in correct
 // order depending on dependencies. It could do it in parallell but has
-// been limited to do one at the time to not mess up output now
+// been limited to do one at the time to not mess up output now.
+// Stops at first error.
 func (c ParsedFileCollection) Walk(walkerFunc WalkFunc) error {
+	return c.WalkWithOptions(walkerFunc, nil).Err()
+}
+
+// WalkWithOptions travers the files in collection as Walk, but options can change order
+// and continue after errors. Returns the result of all files.
+func (c ParsedFileCollection) WalkWithOptions(walkerFunc WalkFunc, options *WalkOptions) *WalkResult {
+	if options == nil {
+		options = &WalkOptions{}
+	}
+
 	graph := &dag.AcyclicGraph{}
 
 	for _, file := range c {
@@ -45,23 +127,86 @@ func (c ParsedFileCollection) Walk(walkerFunc WalkFunc) error {
 
 	for _, file := range c {
 		for _, dep := range file.Dependencies {
-			if contains(c, dep) {
+			if !contains(c, dep) {
+				continue
+			}
+
+			if options.Reverse {
+				graph.Connect(dag.BasicEdge(dep, file))
+			} else {
 				graph.Connect(dag.BasicEdge(file, dep))
 			}
 		}
 	}
 
-	return graph.Walk(func(vertex dag.Vertex) tfdiags.Diagnostics {
+	visited := map[*ParsedFile]error{}
+	failed := false
+
+	graph.Walk(func(vertex dag.Vertex) tfdiags.Diagnostics {
 		var diags tfdiags.Diagnostics
 
 		lock.Lock()
 		defer lock.Unlock()
-		if err := walkerFunc(vertex.(*ParsedFile)); err != nil {
+
+		file := vertex.(*ParsedFile)
+
+		if failed && !options.KeepGoing {
+			return diags.Append(walkStopped)
+		}
+
+		err := walkerFunc(file)
+		visited[file] = err
+
+		if err != nil {
+			failed = true
 			return diags.Append(err)
 		}
 
 		return diags
-	}).Err()
+	})
+
+	result := &WalkResult{
+		Succeeded: []*ParsedFile{},
+		Failed:    []*ParsedFile{},
+		Skipped:   []*ParsedFile{},
+		Stopped:   []*ParsedFile{},
+		Errors:    map[*ParsedFile]error{},
+	}
+
+	for _, file := range c {
+		err, ok := visited[file]
+
+		switch {
+		case !ok && dependencyFailed(graph, file, visited):
+			result.Skipped = append(result.Skipped, file)
+		case !ok:
+			result.Stopped = append(result.Stopped, file)
+		case err != nil:
+			result.Failed = append(result.Failed, file)
+			result.Errors[file] = err
+		default:
+			result.Succeeded = append(result.Succeeded, file)
+		}
+	}
+
+	return result
+}
+
+// dependencyFailed returns true if any file that file depends on in graph, directly or
+// through other files, failed
+func dependencyFailed(graph *dag.AcyclicGraph, file *ParsedFile, visited map[*ParsedFile]error) bool {
+	deps, err := graph.Ancestors(file)
+	if err != nil {
+		return false
+	}
+
+	for _, dep := range deps.List() {
+		if err := visited[dep.(*ParsedFile)]; err != nil {
+			return true
+		}
+	}
+
+	return false
 }
 
 func contains(list []*ParsedFile, item *ParsedFile) bool {
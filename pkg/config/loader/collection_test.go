@@ -110,3 +110,170 @@ func TestCollectionVisit(t *testing.T) {
 		})
 	}
 }
+
+func TestCollectionWalkWithOptions(t *testing.T) {
+	tests := []struct {
+		Input     ParsedFileCollection
+		Fail      []*ParsedFile
+		Options   *WalkOptions
+		Succeeded []*ParsedFile
+		Failed    []*ParsedFile
+		Skipped   []*ParsedFile
+		Stopped   []*ParsedFile
+	}{
+		{
+			[]*ParsedFile{modA, modB, modC},
+			[]*ParsedFile{},
+			&WalkOptions{KeepGoing: true},
+			[]*ParsedFile{modA, modB, modC},
+			[]*ParsedFile{},
+			[]*ParsedFile{},
+			[]*ParsedFile{},
+		},
+		{
+			[]*ParsedFile{modA, modG, modI, modK, modB},
+			[]*ParsedFile{modG},
+			&WalkOptions{KeepGoing: true},
+			[]*ParsedFile{modA, modB},
+			[]*ParsedFile{modG},
+			[]*ParsedFile{modI, modK},
+			[]*ParsedFile{},
+		},
+		{
+			[]*ParsedFile{modA, modD, modE, modB},
+			[]*ParsedFile{modA},
+			&WalkOptions{KeepGoing: true},
+			[]*ParsedFile{modB},
+			[]*ParsedFile{modA},
+			[]*ParsedFile{modD, modE},
+			[]*ParsedFile{},
+		},
+		{
+			[]*ParsedFile{modA, modD, modE, modB},
+			[]*ParsedFile{modA, modB},
+			&WalkOptions{KeepGoing: true},
+			[]*ParsedFile{},
+			[]*ParsedFile{modA, modB},
+			[]*ParsedFile{modD, modE},
+			[]*ParsedFile{},
+		},
+		{
+			[]*ParsedFile{modA, modG, modI},
+			[]*ParsedFile{modI},
+			&WalkOptions{KeepGoing: true, Reverse: true},
+			[]*ParsedFile{},
+			[]*ParsedFile{modI},
+			[]*ParsedFile{modA, modG},
+			[]*ParsedFile{},
+		},
+		{
+			[]*ParsedFile{modA, modG, modI},
+			[]*ParsedFile{modA},
+			&WalkOptions{KeepGoing: true, Reverse: true},
+			[]*ParsedFile{modG, modI},
+			[]*ParsedFile{modA},
+			[]*ParsedFile{},
+			[]*ParsedFile{},
+		},
+		{
+			[]*ParsedFile{modA, modD, modE},
+			[]*ParsedFile{modA},
+			&WalkOptions{},
+			[]*ParsedFile{},
+			[]*ParsedFile{modA},
+			[]*ParsedFile{modD, modE},
+			[]*ParsedFile{},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			result := test.Input.WalkWithOptions(func(file *ParsedFile) error {
+				for _, fail := range test.Fail {
+					if file == fail {
+						return fmt.Errorf("%s failed", file.Name)
+					}
+				}
+
+				return nil
+			}, test.Options)
+
+			assert.ElementsMatch(t, test.Succeeded, result.Succeeded)
+			assert.ElementsMatch(t, test.Failed, result.Failed)
+			assert.ElementsMatch(t, test.Skipped, result.Skipped)
+			assert.ElementsMatch(t, test.Stopped, result.Stopped)
+
+			if len(test.Fail) > 0 {
+				assert.Error(t, result.Err())
+			} else {
+				assert.NoError(t, result.Err())
+			}
+		})
+	}
+}
+
+func TestCollectionWalkStopsAtFirstError(t *testing.T) {
+	input := ParsedFileCollection{modA, modG, modI}
+	visited := []*ParsedFile{}
+
+	err := input.Walk(func(file *ParsedFile) error {
+		visited = append(visited, file)
+
+		if file == modA {
+			return fmt.Errorf("A failed")
+		}
+
+		return nil
+	})
+
+	assert.EqualError(t, err, "A failed")
+	assert.Equal(t, []*ParsedFile{modA}, visited)
+}
+
+func TestCollectionFilter(t *testing.T) {
+	modVnet := &ParsedFile{File: &config.File{Name: "vnet.hcl"}}
+
+	tests := []struct {
+		Input   ParsedFileCollection
+		Names   []string
+		Expects ParsedFileCollection
+		Error   bool
+	}{
+		{ParsedFileCollection{modA, modB, modC}, []string{"A"}, ParsedFileCollection{modA}, false},
+		{ParsedFileCollection{modA, modB, modC}, []string{"C", "A"}, ParsedFileCollection{modA, modC}, false},
+		{ParsedFileCollection{modA, modVnet}, []string{"vnet"}, ParsedFileCollection{modVnet}, false},
+		{ParsedFileCollection{modA, modVnet}, []string{"vnet.hcl"}, ParsedFileCollection{modVnet}, false},
+		{ParsedFileCollection{modA, modB}, []string{"A", "X"}, nil, true},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			filtered, err := test.Input.Filter(test.Names)
+
+			if test.Error {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, test.Expects, filtered)
+		})
+	}
+}
+
+func TestCollectionWalkStopped(t *testing.T) {
+	input := ParsedFileCollection{modA, modB, modD}
+
+	result := input.WalkWithOptions(func(file *ParsedFile) error {
+		if file == modB {
+			return fmt.Errorf("B failed")
+		}
+
+		return nil
+	}, nil)
+
+	// A and D do not depend on B, so they are either processed before B failed or stopped
+	assert.Equal(t, []*ParsedFile{modB}, result.Failed)
+	assert.Empty(t, result.Skipped)
+	assert.Equal(t, 2, len(result.Succeeded)+len(result.Stopped))
+}
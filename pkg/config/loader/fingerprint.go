@@ -0,0 +1,244 @@
+package loader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/avinor/tau/pkg/config"
+	"github.com/avinor/tau/pkg/helpers/paths"
+)
+
+const (
+	// fingerprintFileName is name of file fingerprint of plan is written to, in module directory
+	fingerprintFileName = "tau.tfplan.fingerprint"
+)
+
+// Fingerprint identifies all inputs a plan was created from. If any of them changes after the
+// plan was created the plan is stale and should not be applied. Each part is a sha256 hash:
+//
+// Config is the source file and all auto imported files, Variables is the content of
+// terraform.tfvars, Module is all files in module directory (except terraform data and plan)
+// and Dependencies is the resolved values from dependencies and data sources.
+type Fingerprint struct {
+	Config       string `json:"config"`
+	Variables    string `json:"variables"`
+	Module       string `json:"module"`
+	Dependencies string `json:"dependencies"`
+
+	// Destroy is true if plan was created to destroy all resources
+	Destroy bool `json:"destroy"`
+}
+
+// Diff returns the name of all parts that are different in other fingerprint
+func (f *Fingerprint) Diff(other *Fingerprint) []string {
+	changes := []string{}
+
+	if f.Config != other.Config {
+		changes = append(changes, "config")
+	}
+
+	if f.Variables != other.Variables {
+		changes = append(changes, "input variables")
+	}
+
+	if f.Module != other.Module {
+		changes = append(changes, "module")
+	}
+
+	if f.Dependencies != other.Dependencies {
+		changes = append(changes, "dependency values")
+	}
+
+	return changes
+}
+
+// FingerprintFile returns name of file where fingerprint of plan is stored
+func (p ParsedFile) FingerprintFile() string {
+	return paths.Join(p.ModuleDir(), fingerprintFileName)
+}
+
+// Fingerprint calculates the fingerprint of current config, input variables, module and
+// dependency values. Dependencies have to be resolved first.
+func (p *ParsedFile) Fingerprint() (*Fingerprint, error) {
+	variables, err := hashFile(p.VariableFile())
+	if err != nil {
+		return nil, err
+	}
+
+	module, err := hashModuleDir(p.ModuleDir())
+	if err != nil {
+		return nil, err
+	}
+
+	dependencies, err := p.dependencyHash()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Fingerprint{
+		Config:       p.configHash(),
+		Variables:    variables,
+		Module:       module,
+		Dependencies: dependencies,
+	}, nil
+}
+
+// WriteFingerprint calculates the fingerprint and writes it to FingerprintFile
+func (p *ParsedFile) WriteFingerprint(destroy bool) error {
+	fingerprint, err := p.Fingerprint()
+	if err != nil {
+		return err
+	}
+
+	fingerprint.Destroy = destroy
+
+	content, err := json.Marshal(fingerprint)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(p.FingerprintFile(), content, os.ModePerm)
+}
+
+// ReadFingerprint reads the fingerprint stored in FingerprintFile. Returns nil if
+// there is no fingerprint stored.
+func (p *ParsedFile) ReadFingerprint() (*Fingerprint, error) {
+	if !paths.IsFile(p.FingerprintFile()) {
+		return nil, nil
+	}
+
+	content, err := ioutil.ReadFile(p.FingerprintFile())
+	if err != nil {
+		return nil, err
+	}
+
+	fingerprint := &Fingerprint{}
+	if err := json.Unmarshal(content, fingerprint); err != nil {
+		return nil, err
+	}
+
+	return fingerprint, nil
+}
+
+// configHash returns a sha256 hash of the source file and all auto imported files
+func (p *ParsedFile) configHash() string {
+	h := sha256.New()
+
+	files := []*config.File{}
+	files = append(files, p.File.Children()...)
+	files = append(files, p.File)
+
+	for _, file := range files {
+		h.Write([]byte(file.Name))
+		h.Write([]byte{0})
+		h.Write(file.Content)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// dependencyHash returns a sha256 hash of the resolved dependency values
+func (p *ParsedFile) dependencyHash() (string, error) {
+	values := p.DependencyValues
+	if values == nil {
+		values = map[string]cty.Value{}
+	}
+
+	obj := cty.ObjectVal(values)
+	content, err := ctyjson.Marshal(obj, obj.Type())
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.Sum256(content)
+
+	return hex.EncodeToString(h[:]), nil
+}
+
+// hashFile returns the sha256 hash of file content, or an empty string if it does not exist
+func hashFile(file string) (string, error) {
+	if !paths.IsFile(file) {
+		return "", nil
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// hashModuleDir returns a sha256 hash of path and content of all files in module directory.
+// Terraform data directory, plan, fingerprint and variable files are not included, as they
+// are changed by tau and terraform.
+func hashModuleDir(dir string) (string, error) {
+	h := sha256.New()
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if strings.HasPrefix(info.Name(), ".terraform") || info.Name() == ".git" {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		switch rel {
+		case "tau.tfplan", fingerprintFileName, "terraform.tfvars":
+			return nil
+		}
+
+		h.Write([]byte(filepath.ToSlash(rel)))
+		h.Write([]byte{0})
+
+		if info.Mode()&os.ModeSymlink != 0 {
+			target, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+
+			h.Write([]byte(target))
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		_, err = io.Copy(h, f)
+		return err
+	})
+
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
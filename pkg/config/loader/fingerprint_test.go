@@ -0,0 +1,72 @@
+package loader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/avinor/tau/pkg/config"
+)
+
+func TestFingerprintDiff(t *testing.T) {
+	tests := []struct {
+		change  func(file *ParsedFile)
+		expects []string
+	}{
+		{func(file *ParsedFile) {}, []string{}},
+		{func(file *ParsedFile) {
+			file.Content = []byte("module { source = \"changed\" }")
+		}, []string{"config"}},
+		{func(file *ParsedFile) {
+			ioutil.WriteFile(file.VariableFile(), []byte("name = \"changed\""), 0644)
+		}, []string{"input variables"}},
+		{func(file *ParsedFile) {
+			ioutil.WriteFile(filepath.Join(file.ModuleDir(), "main.tf"), []byte("# changed"), 0644)
+		}, []string{"module"}},
+		{func(file *ParsedFile) {
+			file.DependencyValues = map[string]cty.Value{"dependency.vnet.outputs.id": cty.StringVal("changed")}
+		}, []string{"dependency values"}},
+		{func(file *ParsedFile) {
+			ioutil.WriteFile(file.PlanFile(), []byte("changed"), 0644)
+			os.MkdirAll(filepath.Join(file.ModuleDir(), ".terraform"), 0755)
+			ioutil.WriteFile(filepath.Join(file.ModuleDir(), ".terraform", "state"), []byte("changed"), 0644)
+		}, []string{}},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "tau-fingerprint")
+			assert.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "main.tf"), []byte("# module"), 0644))
+			assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "terraform.tfvars"), []byte("name = \"test\""), 0644))
+
+			file := &ParsedFile{
+				File: &config.File{
+					Name:    "test.hcl",
+					Content: []byte("module { source = \"test\" }"),
+				},
+				DependencyValues: map[string]cty.Value{"dependency.vnet.outputs.id": cty.StringVal("id")},
+				moduleDir:        dir,
+			}
+
+			assert.NoError(t, file.WriteFingerprint(false))
+
+			test.change(file)
+
+			planned, err := file.ReadFingerprint()
+			assert.NoError(t, err)
+
+			current, err := file.Fingerprint()
+			assert.NoError(t, err)
+
+			assert.Equal(t, test.expects, planned.Diff(current))
+		})
+	}
+}
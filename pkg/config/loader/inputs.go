@@ -0,0 +1,110 @@
+package loader
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+
+	"github.com/hashicorp/hcl/v2"
+
+	"github.com/avinor/tau/pkg/helpers/paths"
+	"github.com/avinor/tau/pkg/shell"
+)
+
+// InputsHash records what the input variables file was generated from, so it can be
+// regenerated if configuration or dependency values change. Config is a hash of source
+// file and all auto imported files, Dependencies a hash of resolved dependency values.
+type InputsHash struct {
+	Config       string `json:"config"`
+	Dependencies string `json:"dependencies"`
+}
+
+// InputsHashFile returns name of file where hash of inputs used to generate input
+// variables file is stored
+func (p ParsedFile) InputsHashFile() string {
+	return paths.Join(p.TempDir, "inputs.json")
+}
+
+// InputsHash calculates the hash of current configuration and dependency values
+func (p *ParsedFile) InputsHash() (*InputsHash, error) {
+	dependencies, err := p.dependencyHash()
+	if err != nil {
+		return nil, err
+	}
+
+	return &InputsHash{
+		Config:       p.configHash(),
+		Dependencies: dependencies,
+	}, nil
+}
+
+// WriteInputsHash calculates hash of inputs and writes it to InputsHashFile. Should
+// be called every time input variables file is written.
+func (p *ParsedFile) WriteInputsHash() error {
+	hash, err := p.InputsHash()
+	if err != nil {
+		return err
+	}
+
+	content, err := json.Marshal(hash)
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(p.InputsHashFile(), content, os.ModePerm)
+}
+
+// ReadInputsHash reads the hash stored in InputsHashFile. Returns nil if no hash is stored.
+func (p *ParsedFile) ReadInputsHash() (*InputsHash, error) {
+	if !paths.IsFile(p.InputsHashFile()) {
+		return nil, nil
+	}
+
+	content, err := ioutil.ReadFile(p.InputsHashFile())
+	if err != nil {
+		return nil, err
+	}
+
+	hash := &InputsHash{}
+	if err := json.Unmarshal(content, hash); err != nil {
+		return nil, err
+	}
+
+	return hash, nil
+}
+
+// SensitiveInputs returns names of input variables that should be redacted when printed,
+// because their value is read from a data source or a dependency, or their name indicates
+// it is a secret. Dependency outputs are read without knowing if they are marked sensitive
+// in dependency, so all of them are treated as sensitive.
+func (p ParsedFile) SensitiveInputs() (map[string]bool, error) {
+	sensitive := map[string]bool{}
+
+	if p.Config.Inputs == nil {
+		return sensitive, nil
+	}
+
+	attrs, diags := p.Config.Inputs.Config.JustAttributes()
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	for name, attr := range attrs {
+		if shell.IsSecretName(name) || referencesResolved(attr.Expr) {
+			sensitive[name] = true
+		}
+	}
+
+	return sensitive, nil
+}
+
+// referencesResolved returns true if expression references a data source or dependency
+func referencesResolved(expr hcl.Expression) bool {
+	for _, traversal := range expr.Variables() {
+		if root := traversal.RootName(); root == "data" || root == "dependency" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,54 @@
+package loader
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/avinor/tau/pkg/config"
+)
+
+func TestSensitiveInputs(t *testing.T) {
+	tests := []struct {
+		Content string
+		Expects map[string]bool
+	}{
+		{
+			"module { source = \"test\" }",
+			map[string]bool{},
+		},
+		{
+			"inputs {\n name = \"test\"\n subnet = dependency.vnet.outputs.id\n}",
+			map[string]bool{"subnet": true},
+		},
+		{
+			"inputs {\n conn = \"Server=${dependency.db.outputs.connection_string}\"\n}",
+			map[string]bool{"conn": true},
+		},
+		{
+			"inputs {\n name = \"test\"\n password = data.azurerm_key_vault_secret.pwd.value\n}",
+			map[string]bool{"password": true},
+		},
+		{
+			"inputs {\n client_secret = \"secret\"\n tags = { owner = data.azurerm_client_config.current.object_id }\n}",
+			map[string]bool{"client_secret": true, "tags": true},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			f, err := config.NewFile(fmt.Sprintf("/sensitive%02d.hcl", i), []byte(test.Content))
+			assert.NoError(t, err)
+
+			cfg, err := f.Config()
+			assert.NoError(t, err)
+
+			file := &ParsedFile{File: f, Config: cfg}
+
+			sensitive, err := file.SensitiveInputs()
+			assert.NoError(t, err)
+			assert.Equal(t, test.Expects, sensitive)
+		})
+	}
+}
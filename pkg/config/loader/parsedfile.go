@@ -1,13 +1,30 @@
 package loader
 
 import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
 
 	"github.com/avinor/tau/pkg/config"
+	filelock "github.com/avinor/tau/pkg/helpers/lock"
 	"github.com/avinor/tau/pkg/helpers/paths"
+	"github.com/avinor/tau/pkg/shell"
+)
+
+const (
+	// LogDirName is name of directory in temporary directory of deployment logs are written to
+	LogDirName = "logs"
+
+	// logTimeFormat is format of timestamp in log file names
+	logTimeFormat = "20060102T150405"
 )
 
 var (
@@ -28,7 +45,17 @@ type ParsedFile struct {
 	Dependencies map[string]*ParsedFile
 	ShouldDelete bool
 
+	// Outputs from module, only set after outputs have been read from module
+	Outputs map[string]cty.Value
+
+	// DependencyValues are the resolved values from dependencies and data sources, only
+	// set after dependencies have been resolved
+	DependencyValues map[string]cty.Value
+
 	moduleDir string
+
+	// ctx is the context commands for this deployment run with
+	ctx context.Context
 }
 
 // NewParsedFile creates a new parsed file from input parameters. It does not try to read the file
@@ -85,6 +112,11 @@ func NewParsedFile(filename string, content []byte, tauDir, cacheDir string) (*P
 	}, nil
 }
 
+// DeploymentName returns name of deployment, the file name without extension
+func (p ParsedFile) DeploymentName() string {
+	return strings.TrimSuffix(p.Name, filepath.Ext(p.Name))
+}
+
 // ModuleDir returns the module directory where source module is downloaded
 func (p ParsedFile) ModuleDir() string {
 	return p.moduleDir
@@ -111,6 +143,86 @@ func (p ParsedFile) VariableFile() string {
 	return paths.Join(p.ModuleDir(), "terraform.tfvars")
 }
 
+// OutputFile returns name of file where module outputs are written as json
+func (p ParsedFile) OutputFile() string {
+	return paths.Join(p.TempDir, "outputs.json")
+}
+
+// SetOutputs sets the output values read from module and writes them to OutputFile
+// so they are available for hooks.
+func (p *ParsedFile) SetOutputs(values map[string]cty.Value) error {
+	obj := cty.ObjectVal(values)
+	bytes, err := ctyjson.Marshal(obj, obj.Type())
+	if err != nil {
+		return err
+	}
+
+	// Outputs can contain sensitive values, so only owner can read the file. Existing file is
+	// removed first, as permissions are not changed when writing to an existing file
+	if err := os.Remove(p.OutputFile()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := ioutil.WriteFile(p.OutputFile(), bytes, 0600); err != nil {
+		return err
+	}
+
+	p.Outputs = values
+
+	return nil
+}
+
+// Context returns the context commands for this deployment should run with
+func (p ParsedFile) Context() context.Context {
+	if p.ctx == nil {
+		return context.Background()
+	}
+
+	return p.ctx
+}
+
+// SetContext sets the context commands for this deployment should run with, for instance
+// to stop them after a timeout
+func (p *ParsedFile) SetContext(ctx context.Context) {
+	p.ctx = ctx
+}
+
+// RetryPolicy returns the policy for retrying terraform commands that fail with transient
+// errors, using defaults if config does not have a retry block
+func (p ParsedFile) RetryPolicy() *shell.RetryPolicy {
+	return &shell.RetryPolicy{
+		Patterns:    p.Config.Retry.GetPatterns(),
+		MaxAttempts: p.Config.Retry.GetMaxAttempts(),
+		Backoff:     p.Config.Retry.GetBackoff(),
+	}
+}
+
+// ApplyRetryPolicy returns the policy for retrying terraform apply. It is same as RetryPolicy,
+// except that without a retry block it only retries errors where nothing has been applied yet.
+func (p ParsedFile) ApplyRetryPolicy() *shell.RetryPolicy {
+	policy := p.RetryPolicy()
+	policy.Patterns = p.Config.Retry.GetApplyPatterns()
+
+	return policy
+}
+
+// LogDir returns the directory where logs from commands executed for this deployment
+// are written
+func (p ParsedFile) LogDir() string {
+	return paths.Join(p.TempDir, LogDirName)
+}
+
+// LogFile returns name of log file for command started at time t. Names sort in the
+// order commands were started.
+func (p ParsedFile) LogFile(command string, t time.Time) string {
+	return paths.Join(p.LogDir(), fmt.Sprintf("%s-%s.log", t.Format(logTimeFormat), command))
+}
+
+// LockFile returns name of lock file used to lock this deployment
+func (p ParsedFile) LockFile() string {
+	return p.TempDir + filelock.Extension
+}
+
 // IsInitialized returns true if the module has been initialized already
 func (p ParsedFile) IsInitialized() bool {
 	return paths.IsDir(p.ModuleDir())
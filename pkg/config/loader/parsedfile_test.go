@@ -0,0 +1,32 @@
+package loader
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestSetOutputs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tau-outputs")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := &ParsedFile{TempDir: dir}
+
+	// Existing file with wider permissions is replaced
+	assert.NoError(t, ioutil.WriteFile(file.OutputFile(), []byte("{}"), 0644))
+
+	assert.NoError(t, file.SetOutputs(map[string]cty.Value{"password": cty.StringVal("secret")}))
+
+	info, err := os.Stat(filepath.Join(dir, "outputs.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	content, err := ioutil.ReadFile(file.OutputFile())
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"password":"secret"}`, string(content))
+}
@@ -0,0 +1,179 @@
+package config
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// retryPatternIncorrect is returned if a pattern is not a valid regular expression
+	retryPatternIncorrect = errors.Errorf("retry patterns have to be valid regular expressions")
+
+	// maxAttemptsValueIncorrect is returned if max_attempts is less than 1
+	maxAttemptsValueIncorrect = errors.Errorf("retry max_attempts has to be at least 1")
+
+	// backoffValueIncorrect is returned if backoff is not a valid duration
+	backoffValueIncorrect = errors.Errorf("retry backoff has to be a valid duration, for instance 10s")
+
+	// DefaultRetryPatterns are the patterns matching transient errors from terraform, used
+	// if retry block does not define patterns
+	DefaultRetryPatterns = []string{
+		`Error acquiring the state lock`,
+		`Too Many Requests`,
+		`StatusCode=429`,
+		`TLS handshake timeout`,
+		`connection reset by peer`,
+		`i/o timeout`,
+		`Failed to query available provider packages`,
+		`Error installing provider`,
+		`registry service is unreachable`,
+	}
+
+	// DefaultApplyRetryPatterns are the patterns used for apply if there is no retry block.
+	// Apply can fail with a transient error after some resources are changed, and then it
+	// cannot be retried, so only errors before anything is applied are retried by default.
+	DefaultApplyRetryPatterns = []string{
+		`Error acquiring the state lock`,
+	}
+
+	// DefaultRetryMaxAttempts is number of attempts if max_attempts is not set
+	DefaultRetryMaxAttempts = 3
+
+	// DefaultRetryBackoff is the time to wait before first retry if backoff is not set
+	DefaultRetryBackoff = 10 * time.Second
+)
+
+// Retry is the policy for retrying terraform commands that fail with transient errors, like
+// a state lock held by another process or rate limiting from cloud API. If a command fails
+// and a line written to stderr matches one of the patterns (regular expressions) the command
+// is retried, until it has been run max_attempts times. Time to wait is doubled for every
+// retry, starting with backoff.
+//
+// If there is no retry block, or attributes are not set, the defaults are used. Set
+// max_attempts to 1 to disable retries. Without a retry block apply only retries the
+// DefaultApplyRetryPatterns.
+type Retry struct {
+	Patterns    *[]string `hcl:"patterns,attr"`
+	MaxAttempts *int      `hcl:"max_attempts,attr"`
+	Backoff     *string   `hcl:"backoff,attr"`
+}
+
+// Merge current retry with config from source, attributes in source overwrite current
+func (r *Retry) Merge(src *Retry) error {
+	if src == nil {
+		return nil
+	}
+
+	if src.Patterns != nil {
+		r.Patterns = src.Patterns
+	}
+
+	r.MaxAttempts = setFirstIntPointer(src.MaxAttempts, r.MaxAttempts)
+	r.Backoff = setFirstStringPointer(src.Backoff, r.Backoff)
+
+	return nil
+}
+
+// Validate that patterns are valid regular expressions, max_attempts is at least 1 and
+// backoff is a valid duration
+func (r Retry) Validate() (bool, error) {
+	if r.Patterns != nil {
+		for _, pattern := range *r.Patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				return false, errors.Wrap(retryPatternIncorrect, err.Error())
+			}
+		}
+	}
+
+	if r.MaxAttempts != nil && *r.MaxAttempts < 1 {
+		return false, maxAttemptsValueIncorrect
+	}
+
+	if r.Backoff != nil {
+		if backoff, err := time.ParseDuration(*r.Backoff); err != nil || backoff < 0 {
+			return false, backoffValueIncorrect
+		}
+	}
+
+	return true, nil
+}
+
+// GetPatterns returns the compiled patterns, DefaultRetryPatterns if patterns are not set.
+// Invalid patterns are ignored, they are reported by Validate.
+func (r *Retry) GetPatterns() []*regexp.Regexp {
+	patterns := DefaultRetryPatterns
+	if r != nil && r.Patterns != nil {
+		patterns = *r.Patterns
+	}
+
+	return compilePatterns(patterns)
+}
+
+// GetApplyPatterns returns the compiled patterns for apply, DefaultApplyRetryPatterns if
+// there is no retry block, otherwise same as GetPatterns
+func (r *Retry) GetApplyPatterns() []*regexp.Regexp {
+	if r == nil {
+		return compilePatterns(DefaultApplyRetryPatterns)
+	}
+
+	return r.GetPatterns()
+}
+
+// compilePatterns compiles patterns, invalid patterns are ignored
+func compilePatterns(patterns []string) []*regexp.Regexp {
+	compiled := []*regexp.Regexp{}
+	for _, pattern := range patterns {
+		if re, err := regexp.Compile(pattern); err == nil {
+			compiled = append(compiled, re)
+		}
+	}
+
+	return compiled
+}
+
+// GetMaxAttempts returns maximum number of times to run command, DefaultRetryMaxAttempts
+// if max_attempts is not set
+func (r *Retry) GetMaxAttempts() int {
+	if r == nil || r.MaxAttempts == nil {
+		return DefaultRetryMaxAttempts
+	}
+
+	return *r.MaxAttempts
+}
+
+// GetBackoff returns the duration to wait before first retry, DefaultRetryBackoff if
+// backoff is not set
+func (r *Retry) GetBackoff() time.Duration {
+	if r == nil || r.Backoff == nil {
+		return DefaultRetryBackoff
+	}
+
+	backoff, err := time.ParseDuration(*r.Backoff)
+	if err != nil {
+		return DefaultRetryBackoff
+	}
+
+	return backoff
+}
+
+// mergeRetries merges only the retry blocks from all configurations in srcs into dest
+func mergeRetries(dest *Config, srcs []*Config) error {
+	for _, src := range srcs {
+		if src.Retry == nil {
+			continue
+		}
+
+		if dest.Retry == nil {
+			dest.Retry = src.Retry
+			continue
+		}
+
+		if err := dest.Retry.Merge(src.Retry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,158 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/avinor/tau/pkg/helpers/strings"
+)
+
+const (
+	retryTest1 = `
+		retry {
+			patterns = ["Error acquiring the state lock"]
+			max_attempts = 5
+		}
+	`
+
+	retryTest2 = `
+		retry {
+			backoff = "1m"
+		}
+	`
+
+	retryTest3 = `
+		retry {
+			patterns = ["[invalid"]
+		}
+	`
+
+	retryTest4 = `
+		retry {
+			max_attempts = 0
+		}
+	`
+
+	retryTest5 = `
+		retry {
+			backoff = "soon"
+		}
+	`
+)
+
+var (
+	retryFile1, _ = NewFile("/retry1", []byte(retryTest1))
+	retryFile2, _ = NewFile("/retry2", []byte(retryTest2))
+	retryFile3, _ = NewFile("/retry3", []byte(retryTest3))
+	retryFile4, _ = NewFile("/retry4", []byte(retryTest4))
+	retryFile5, _ = NewFile("/retry5", []byte(retryTest5))
+)
+
+func TestRetryMerge(t *testing.T) {
+	maxAttempts := 5
+
+	tests := []struct {
+		Files    []*File
+		Expected *Retry
+	}{
+		{
+			[]*File{retryFile1},
+			&Retry{
+				Patterns:    &[]string{"Error acquiring the state lock"},
+				MaxAttempts: &maxAttempts,
+			},
+		},
+		{
+			[]*File{retryFile1, retryFile2},
+			&Retry{
+				Patterns:    &[]string{"Error acquiring the state lock"},
+				MaxAttempts: &maxAttempts,
+				Backoff:     strings.ToPointer("1m"),
+			},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			config := &Config{}
+			err := mergeRetries(config, getConfigFromFiles(t, test.Files))
+			assert.NoError(t, err)
+
+			assert.Equal(t, test.Expected, config.Retry)
+		})
+	}
+}
+
+func TestRetryValidation(t *testing.T) {
+	tests := []struct {
+		File     *File
+		Expected ValidationResult
+	}{
+		{retryFile1, ValidationResult{Result: true, Error: nil}},
+		{retryFile2, ValidationResult{Result: true, Error: nil}},
+		{retryFile3, ValidationResult{Result: false, Error: retryPatternIncorrect}},
+		{retryFile4, ValidationResult{Result: false, Error: maxAttemptsValueIncorrect}},
+		{retryFile5, ValidationResult{Result: false, Error: backoffValueIncorrect}},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			config := getConfigFromFiles(t, []*File{test.File})[0]
+
+			valid, err := config.Retry.Validate()
+			assert.Equal(t, test.Expected.Result, valid)
+
+			if test.Expected.Error != nil {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), test.Expected.Error.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestRetryDefaults(t *testing.T) {
+	var retry *Retry
+
+	assert.Len(t, retry.GetPatterns(), len(DefaultRetryPatterns))
+	assert.Len(t, retry.GetApplyPatterns(), len(DefaultApplyRetryPatterns))
+	assert.Equal(t, DefaultRetryMaxAttempts, retry.GetMaxAttempts())
+	assert.Equal(t, DefaultRetryBackoff, retry.GetBackoff())
+
+	config := getConfigFromFiles(t, []*File{retryFile2})[0]
+
+	assert.Len(t, config.Retry.GetPatterns(), len(DefaultRetryPatterns))
+	assert.Len(t, config.Retry.GetApplyPatterns(), len(DefaultRetryPatterns))
+	assert.Equal(t, DefaultRetryMaxAttempts, config.Retry.GetMaxAttempts())
+	assert.Equal(t, time.Minute, config.Retry.GetBackoff())
+}
+
+func TestRetryApplyPatterns(t *testing.T) {
+	var retry *Retry
+
+	tests := []struct {
+		Line     string
+		Expected bool
+	}{
+		{"Error: Error acquiring the state lock", true},
+		{"Error: read tcp: i/o timeout", false},
+		{"StatusCode=429", false},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			matched := false
+			for _, pattern := range retry.GetApplyPatterns() {
+				if pattern.MatchString(test.Line) {
+					matched = true
+				}
+			}
+
+			assert.Equal(t, test.Expected, matched)
+		})
+	}
+}
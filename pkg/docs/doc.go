@@ -0,0 +1,3 @@
+// Package docs generates documentation of deployments from their configuration, for instance
+// to keep a catalog of deployments in README up to date.
+package docs
@@ -0,0 +1,378 @@
+package docs
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/avinor/tau/pkg/config/loader"
+)
+
+var (
+	// backendKeyAttributes are attributes in backend configuration that identify the state,
+	// in order of precedence
+	backendKeyAttributes = []string{"key", "prefix", "path"}
+
+	// newLineRegexp matches line breaks and indentation in expressions
+	newLineRegexp = regexp.MustCompile(`\n\s*`)
+)
+
+// Deployment documents the configuration of a single deployment
+type Deployment struct {
+	Name         string        `json:"name"`
+	File         string        `json:"file"`
+	Module       *Module       `json:"module,omitempty"`
+	Backend      *Backend      `json:"backend,omitempty"`
+	Dependencies []*Dependency `json:"dependencies"`
+	Hooks        []*Hook       `json:"hooks"`
+	Inputs       []*Input      `json:"inputs"`
+}
+
+// Module is the source and version of module deployed
+type Module struct {
+	Source  string `json:"source"`
+	Version string `json:"version,omitempty"`
+}
+
+// Backend is the type of backend and the key state is stored with, if it could be read
+type Backend struct {
+	Type string `json:"type"`
+	Key  string `json:"key,omitempty"`
+}
+
+// Dependency is a dependency of deployment, with the outputs that are consumed from it.
+// Deployment is name of the deployment it references, if it was loaded.
+type Dependency struct {
+	Name       string   `json:"name"`
+	Source     string   `json:"source"`
+	Deployment string   `json:"deployment,omitempty"`
+	Outputs    []string `json:"outputs"`
+}
+
+// Hook is a hook that runs for deployment, with the event it triggers on and the command,
+// script or inline script it runs
+type Hook struct {
+	Name      string `json:"name"`
+	TriggerOn string `json:"trigger_on"`
+	Command   string `json:"command"`
+}
+
+// Input is an input variable sent to module. Value is the value on a single line, or the
+// expression if value cannot be evaluated without resolving dependencies. Value is not set
+// for sensitive inputs.
+type Input struct {
+	Name      string `json:"name"`
+	Value     string `json:"value,omitempty"`
+	Sensitive bool   `json:"sensitive"`
+}
+
+// Generate creates documentation of all deployments in files, sorted by name. It only reads
+// the configuration, so dependencies and data sources are not resolved.
+func Generate(files loader.ParsedFileCollection) ([]*Deployment, error) {
+	deployments := []*Deployment{}
+
+	for _, file := range files {
+		deployment, err := newDeployment(file)
+		if err != nil {
+			return nil, err
+		}
+
+		deployments = append(deployments, deployment)
+	}
+
+	sort.Slice(deployments, func(i, j int) bool {
+		return deployments[i].Name < deployments[j].Name
+	})
+
+	return deployments, nil
+}
+
+// newDeployment creates documentation of a single deployment
+func newDeployment(file *loader.ParsedFile) (*Deployment, error) {
+	cfg := file.Config
+
+	deployment := &Deployment{
+		Name:         file.DeploymentName(),
+		File:         file.Name,
+		Dependencies: []*Dependency{},
+		Hooks:        []*Hook{},
+		Inputs:       []*Input{},
+	}
+
+	if cfg.Module != nil {
+		deployment.Module = &Module{
+			Source:  cfg.Module.Source,
+			Version: cfg.Module.Version,
+		}
+	}
+
+	if cfg.Backend != nil {
+		deployment.Backend = &Backend{
+			Type: cfg.Backend.Type,
+			Key:  backendKey(file),
+		}
+	}
+
+	attrs := hcl.Attributes{}
+	if cfg.Inputs != nil {
+		var diags hcl.Diagnostics
+		if attrs, diags = cfg.Inputs.Config.JustAttributes(); diags.HasErrors() {
+			return nil, diags
+		}
+	}
+
+	consumed := consumedOutputs(file, attrs)
+
+	for _, dep := range cfg.Dependencies {
+		dependency := &Dependency{
+			Name:    dep.Name,
+			Source:  dep.Source,
+			Outputs: consumed[dep.Name],
+		}
+
+		if dependency.Outputs == nil {
+			dependency.Outputs = []string{}
+		}
+
+		if depFile, ok := file.Dependencies[dep.Name]; ok {
+			dependency.Deployment = depFile.DeploymentName()
+		}
+
+		deployment.Dependencies = append(deployment.Dependencies, dependency)
+	}
+
+	for _, hook := range cfg.Hooks {
+		deployment.Hooks = append(deployment.Hooks, &Hook{
+			Name:      hook.Type,
+			TriggerOn: stringValue(hook.TriggerOn),
+			Command:   hookCommand(hook.Command, hook.Script, hook.Inline),
+		})
+	}
+
+	sensitive, err := file.SensitiveInputs()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range sortedNames(attrs) {
+		input := &Input{
+			Name:      name,
+			Sensitive: sensitive[name],
+		}
+
+		if !input.Sensitive {
+			input.Value = inputValue(file, attrs[name].Expr)
+		}
+
+		deployment.Inputs = append(deployment.Inputs, input)
+	}
+
+	return deployment, nil
+}
+
+// backendKey returns the key state is stored with in backend, or empty string if backend
+// configuration has no key. If key calls a function the expression is returned, see inputValue.
+func backendKey(file *loader.ParsedFile) string {
+	attrs, diags := file.Config.Backend.Config.JustAttributes()
+	if diags.HasErrors() {
+		return ""
+	}
+
+	for _, name := range backendKeyAttributes {
+		attr, ok := attrs[name]
+		if !ok {
+			continue
+		}
+
+		if callsFunction(attr.Expr) {
+			return expressionSource(file, attr.Expr)
+		}
+
+		value, diags := attr.Expr.Value(file.EvalContext())
+		if diags.HasErrors() || value.IsNull() || !value.IsKnown() || value.Type() != cty.String {
+			return expressionSource(file, attr.Expr)
+		}
+
+		return value.AsString()
+	}
+
+	return ""
+}
+
+// consumedOutputs returns the outputs referenced from each dependency, in inputs and data
+// sources, as dependency.<name>.outputs.<output>
+func consumedOutputs(file *loader.ParsedFile, attrs hcl.Attributes) map[string][]string {
+	traversals := []hcl.Traversal{}
+
+	for _, attr := range attrs {
+		traversals = append(traversals, attr.Expr.Variables()...)
+	}
+
+	for _, data := range file.Config.Datas {
+		// Data sources with nested blocks cannot be decoded, so they are not included
+		if vars, err := data.ResolveVariables(data.Config); err == nil {
+			traversals = append(traversals, vars...)
+		}
+	}
+
+	found := map[string]map[string]bool{}
+
+	for _, traversal := range traversals {
+		if traversal.RootName() != "dependency" || len(traversal) < 4 {
+			continue
+		}
+
+		name, ok := attributeName(traversal[1])
+		if !ok {
+			continue
+		}
+
+		if attr, ok := attributeName(traversal[2]); !ok || attr != "outputs" {
+			continue
+		}
+
+		output, ok := attributeName(traversal[3])
+		if !ok {
+			continue
+		}
+
+		if found[name] == nil {
+			found[name] = map[string]bool{}
+		}
+
+		found[name][output] = true
+	}
+
+	consumed := map[string][]string{}
+	for name, outputs := range found {
+		for output := range outputs {
+			consumed[name] = append(consumed[name], output)
+		}
+
+		sort.Strings(consumed[name])
+	}
+
+	return consumed
+}
+
+// attributeName returns the name of attribute for traverser, either as attribute or
+// an index with a string key
+func attributeName(traverser hcl.Traverser) (string, bool) {
+	switch t := traverser.(type) {
+	case hcl.TraverseAttr:
+		return t.Name, true
+	case hcl.TraverseIndex:
+		if t.Key.Type() == cty.String && t.Key.IsKnown() && !t.Key.IsNull() {
+			return t.Key.AsString(), true
+		}
+	}
+
+	return "", false
+}
+
+// inputValue returns value of expression formatted on a single line. If it cannot
+// be evaluated, for instance if it references a dependency, the expression is returned.
+//
+// Expressions calling functions are never evaluated, as functions like env and file could
+// read secrets from environment documentation is generated in.
+func inputValue(file *loader.ParsedFile, expr hcl.Expression) string {
+	if callsFunction(expr) {
+		return expressionSource(file, expr)
+	}
+
+	value, diags := expr.Value(file.EvalContext())
+	if diags.HasErrors() || !value.IsWhollyKnown() {
+		return expressionSource(file, expr)
+	}
+
+	// Collections and objects are written as json, which is also valid hcl, to fit on one line
+	if !value.IsNull() && !value.Type().IsPrimitiveType() {
+		if bytes, err := ctyjson.Marshal(value, value.Type()); err == nil {
+			return string(bytes)
+		}
+	}
+
+	return strings.TrimSpace(string(hclwrite.TokensForValue(value).Bytes()))
+}
+
+// expressionSource returns the source of expression, from file or one of the files
+// auto imported into it
+func expressionSource(file *loader.ParsedFile, expr hcl.Expression) string {
+	rng := expr.Range()
+
+	content := file.Content
+	if rng.Filename != file.FullPath {
+		for _, child := range file.Children() {
+			if child.FullPath == rng.Filename {
+				content = child.Content
+			}
+		}
+	}
+
+	if rng.End.Byte > len(content) {
+		return ""
+	}
+
+	return newLineRegexp.ReplaceAllString(strings.TrimSpace(string(rng.SliceBytes(content))), " ")
+}
+
+// callsFunction returns true if expression, or any expression within it, calls a function.
+// Expressions that are not native hcl syntax cannot be inspected, so they are assumed to.
+func callsFunction(expr hcl.Expression) bool {
+	node, ok := expr.(hclsyntax.Node)
+	if !ok {
+		return true
+	}
+
+	found := false
+	hclsyntax.VisitAll(node, func(n hclsyntax.Node) hcl.Diagnostics {
+		if _, ok := n.(*hclsyntax.FunctionCallExpr); ok {
+			found = true
+		}
+
+		return nil
+	})
+
+	return found
+}
+
+// hookCommand returns the command, script or inline script hook runs
+func hookCommand(command, script, inline *string) string {
+	switch {
+	case command != nil:
+		return *command
+	case script != nil:
+		return *script
+	case inline != nil:
+		return "(inline)"
+	}
+
+	return ""
+}
+
+// stringValue returns value of s, or empty string if s is nil
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
+
+// sortedNames returns names of attributes sorted
+func sortedNames(attrs hcl.Attributes) []string {
+	names := []string{}
+	for name := range attrs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,271 @@
+package docs
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/avinor/tau/pkg/config"
+	"github.com/avinor/tau/pkg/config/loader"
+)
+
+var (
+	vnetContent = `
+		module {
+			source = "avinor/vnet/azurerm"
+			version = "1.2.0"
+		}
+
+		backend "azurerm" {
+			key = "${env("SP_CLIENT_ID")}/vnet.tfstate"
+		}
+
+		inputs {
+			name = "vnet"
+			address_space = ["10.0.0.0/16"]
+		}
+	`
+
+	aksContent = `
+		module {
+			source = "avinor/aks/azurerm"
+		}
+
+		backend "azurerm" {
+			key = "${source.name}.tfstate"
+		}
+
+		dependency "vnet" {
+			source = "./vnet.hcl"
+		}
+
+		data "azurerm_key_vault_secret" "pwd" {
+			name = "pwd"
+			key_vault_id = dependency.vnet.outputs["vault_id"]
+		}
+
+		hook "login" {
+			trigger_on = "prepare"
+			command = "az login"
+		}
+
+		inputs {
+			subnet_id = dependency.vnet.outputs.subnets.aks
+			password = data.azurerm_key_vault_secret.pwd.value
+			client_secret = "secret"
+			client_id = env("SP_CLIENT_ID")
+			location = lower("West Europe")
+			prefix = "${source.name}-${env("SP_CLIENT_ID")}"
+			tags = {
+				owner = "team"
+			}
+		}
+	`
+)
+
+// newTestFile creates a parsed file from content, without resolving anything
+func newTestFile(t *testing.T, filename, content string) *loader.ParsedFile {
+	f, err := config.NewFile(filename, []byte(content))
+	assert.NoError(t, err)
+
+	cfg, err := f.Config()
+	assert.NoError(t, err)
+
+	return &loader.ParsedFile{File: f, Config: cfg, Dependencies: map[string]*loader.ParsedFile{}}
+}
+
+func TestGenerate(t *testing.T) {
+	os.Setenv("SP_CLIENT_ID", "client-id-from-environment")
+	defer os.Unsetenv("SP_CLIENT_ID")
+
+	vnet := newTestFile(t, "/docs/vnet.hcl", vnetContent)
+	aks := newTestFile(t, "/docs/aks.hcl", aksContent)
+	aks.Dependencies["vnet"] = vnet
+
+	deployments, err := Generate(loader.ParsedFileCollection{vnet, aks})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []*Deployment{
+		{
+			Name:    "aks",
+			File:    "aks.hcl",
+			Module:  &Module{Source: "avinor/aks/azurerm"},
+			Backend: &Backend{Type: "azurerm", Key: "aks.tfstate"},
+			Dependencies: []*Dependency{
+				{Name: "vnet", Source: "./vnet.hcl", Deployment: "vnet", Outputs: []string{"subnets", "vault_id"}},
+			},
+			Hooks: []*Hook{
+				{Name: "login", TriggerOn: "prepare", Command: "az login"},
+			},
+			Inputs: []*Input{
+				{Name: "client_id", Value: `env("SP_CLIENT_ID")`},
+				{Name: "client_secret", Sensitive: true},
+				{Name: "location", Value: `lower("West Europe")`},
+				{Name: "password", Sensitive: true},
+				{Name: "prefix", Value: `"${source.name}-${env("SP_CLIENT_ID")}"`},
+				{Name: "subnet_id", Sensitive: true},
+				{Name: "tags", Value: `{"owner":"team"}`},
+			},
+		},
+		{
+			Name:         "vnet",
+			File:         "vnet.hcl",
+			Module:       &Module{Source: "avinor/vnet/azurerm", Version: "1.2.0"},
+			Backend:      &Backend{Type: "azurerm", Key: `"${env("SP_CLIENT_ID")}/vnet.tfstate"`},
+			Dependencies: []*Dependency{},
+			Hooks:        []*Hook{},
+			Inputs: []*Input{
+				{Name: "address_space", Value: `["10.0.0.0/16"]`},
+				{Name: "name", Value: `"vnet"`},
+			},
+		},
+	}, deployments)
+}
+
+func TestWrite(t *testing.T) {
+	deployments := []*Deployment{
+		{
+			Name:    "vnet",
+			File:    "vnet.hcl",
+			Module:  &Module{Source: "avinor/vnet/azurerm", Version: "1.2.0"},
+			Backend: &Backend{Type: "azurerm", Key: "vnet.tfstate"},
+			Dependencies: []*Dependency{
+				{Name: "rg", Source: "./rg.hcl", Deployment: "rg", Outputs: []string{"name"}},
+			},
+			Hooks: []*Hook{},
+			Inputs: []*Input{
+				{Name: "name", Value: `"a|b"`},
+				{Name: "secret", Sensitive: true},
+			},
+		},
+	}
+
+	tests := []struct {
+		Format   string
+		Expected string
+		Error    error
+	}{
+		{
+			"markdown",
+			"## Deployments\n\n" +
+				"| Deployment | Module | Version | Backend | Dependencies |\n" +
+				"|------------|--------|---------|---------|--------------|\n" +
+				"| [vnet](#vnet) | `avinor/vnet/azurerm` | 1.2.0 | azurerm | rg |\n" +
+				"\n### vnet\n\n" +
+				"File: `vnet.hcl`\n" +
+				"\nModule: `avinor/vnet/azurerm` version `1.2.0`\n" +
+				"\nBackend: `azurerm` with key `vnet.tfstate`\n" +
+				"\n#### Dependencies\n\n" +
+				"| Name | Source | Outputs consumed |\n" +
+				"|------|--------|------------------|\n" +
+				"| rg | [./rg.hcl](#rg) | `name` |\n" +
+				"\n#### Inputs\n\n" +
+				"| Name | Value |\n" +
+				"|------|-------|\n" +
+				"| name | `\"a\\|b\"` |\n" +
+				"| secret | *sensitive* |\n",
+			nil,
+		},
+		{
+			"json",
+			`[
+  {
+    "name": "vnet",
+    "file": "vnet.hcl",
+    "module": {
+      "source": "avinor/vnet/azurerm",
+      "version": "1.2.0"
+    },
+    "backend": {
+      "type": "azurerm",
+      "key": "vnet.tfstate"
+    },
+    "dependencies": [
+      {
+        "name": "rg",
+        "source": "./rg.hcl",
+        "deployment": "rg",
+        "outputs": [
+          "name"
+        ]
+      }
+    ],
+    "hooks": [],
+    "inputs": [
+      {
+        "name": "name",
+        "value": "\"a|b\"",
+        "sensitive": false
+      },
+      {
+        "name": "secret",
+        "sensitive": true
+      }
+    ]
+  }
+]
+`,
+			nil,
+		},
+		{
+			"html",
+			"",
+			formatNotSupported,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			var buf bytes.Buffer
+
+			err := Write(&buf, test.Format, deployments)
+			assert.Equal(t, test.Error, err)
+			assert.Equal(t, test.Expected, buf.String())
+		})
+	}
+}
+
+func TestInject(t *testing.T) {
+	tests := []struct {
+		Content  string
+		Doc      string
+		Expected string
+		Error    error
+	}{
+		{
+			"# Title\n<!-- BEGIN_TAU_DOCS -->\n<!-- END_TAU_DOCS -->\nFooter\n",
+			"## Deployments\n",
+			"# Title\n<!-- BEGIN_TAU_DOCS -->\n## Deployments\n<!-- END_TAU_DOCS -->\nFooter\n",
+			nil,
+		},
+		{
+			"<!-- BEGIN_TAU_DOCS -->\nold\ndocs\n<!-- END_TAU_DOCS -->",
+			"new",
+			"<!-- BEGIN_TAU_DOCS -->\nnew\n<!-- END_TAU_DOCS -->",
+			nil,
+		},
+		{
+			"# Title\n<!-- BEGIN_TAU_DOCS -->\n",
+			"new",
+			"",
+			markersNotFound,
+		},
+		{
+			"<!-- END_TAU_DOCS -->\n<!-- BEGIN_TAU_DOCS -->\n",
+			"new",
+			"",
+			markersNotFound,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			result, err := Inject([]byte(test.Content), []byte(test.Doc))
+			assert.Equal(t, test.Error, err)
+			assert.Equal(t, test.Expected, string(result))
+		})
+	}
+}
@@ -0,0 +1,212 @@
+package docs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// BeginMarker marks the start of generated documentation in a file
+	BeginMarker = "<!-- BEGIN_TAU_DOCS -->"
+
+	// EndMarker marks the end of generated documentation in a file
+	EndMarker = "<!-- END_TAU_DOCS -->"
+)
+
+var (
+	// Formats are the formats documentation can be written in
+	Formats = []string{"markdown", "json"}
+
+	// formatNotSupported is returned if format is not one of Formats
+	formatNotSupported = errors.Errorf("docs format has to be one of: %s", strings.Join(Formats, ", "))
+
+	// markersNotFound is returned if file does not contain begin and end markers
+	markersNotFound = errors.Errorf("file has to contain %s and %s to inject documentation", BeginMarker, EndMarker)
+)
+
+// IsValidFormat returns true if format is one of Formats
+func IsValidFormat(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Write writes documentation of deployments to w in format
+func Write(w io.Writer, format string, deployments []*Deployment) error {
+	switch format {
+	case "markdown":
+		return writeMarkdown(w, deployments)
+	case "json":
+		return writeJSON(w, deployments)
+	}
+
+	return formatNotSupported
+}
+
+// Inject replaces the content between BeginMarker and EndMarker in content with doc.
+// Markers are kept, so documentation can be injected again later.
+func Inject(content []byte, doc []byte) ([]byte, error) {
+	begin := bytes.Index(content, []byte(BeginMarker))
+	end := bytes.Index(content, []byte(EndMarker))
+
+	if begin < 0 || end < begin {
+		return nil, markersNotFound
+	}
+
+	var buf bytes.Buffer
+
+	buf.Write(content[:begin+len(BeginMarker)])
+	buf.WriteString("\n")
+	buf.Write(bytes.TrimSpace(doc))
+	buf.WriteString("\n")
+	buf.Write(content[end:])
+
+	return buf.Bytes(), nil
+}
+
+// writeJSON writes deployments as an indented json array
+func writeJSON(w io.Writer, deployments []*Deployment) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(deployments)
+}
+
+// writeMarkdown writes a summary table of all deployments, followed by a section
+// for each deployment
+func writeMarkdown(w io.Writer, deployments []*Deployment) error {
+	var buf bytes.Buffer
+
+	buf.WriteString("## Deployments\n\n")
+	buf.WriteString("| Deployment | Module | Version | Backend | Dependencies |\n")
+	buf.WriteString("|------------|--------|---------|---------|--------------|\n")
+
+	for _, d := range deployments {
+		module, version := "", ""
+		if d.Module != nil {
+			module, version = code(d.Module.Source), d.Module.Version
+		}
+
+		backend := ""
+		if d.Backend != nil {
+			backend = d.Backend.Type
+		}
+
+		deps := []string{}
+		for _, dep := range d.Dependencies {
+			deps = append(deps, dep.Name)
+		}
+
+		fmt.Fprintf(&buf, "| [%s](#%s) | %s | %s | %s | %s |\n", d.Name, anchor(d.Name), module, cell(version), cell(backend), cell(strings.Join(deps, ", ")))
+	}
+
+	for _, d := range deployments {
+		fmt.Fprintf(&buf, "\n### %s\n\n", d.Name)
+		fmt.Fprintf(&buf, "File: %s\n", code(d.File))
+
+		if d.Module != nil {
+			fmt.Fprintf(&buf, "\nModule: %s", code(d.Module.Source))
+			if d.Module.Version != "" {
+				fmt.Fprintf(&buf, " version %s", code(d.Module.Version))
+			}
+			buf.WriteString("\n")
+		}
+
+		if d.Backend != nil {
+			fmt.Fprintf(&buf, "\nBackend: %s", code(d.Backend.Type))
+			if d.Backend.Key != "" {
+				fmt.Fprintf(&buf, " with key %s", code(d.Backend.Key))
+			}
+			buf.WriteString("\n")
+		}
+
+		if len(d.Dependencies) > 0 {
+			buf.WriteString("\n#### Dependencies\n\n")
+			buf.WriteString("| Name | Source | Outputs consumed |\n")
+			buf.WriteString("|------|--------|------------------|\n")
+
+			for _, dep := range d.Dependencies {
+				source := code(dep.Source)
+				if dep.Deployment != "" {
+					source = fmt.Sprintf("[%s](#%s)", dep.Source, anchor(dep.Deployment))
+				}
+
+				outputs := []string{}
+				for _, output := range dep.Outputs {
+					outputs = append(outputs, code(output))
+				}
+
+				fmt.Fprintf(&buf, "| %s | %s | %s |\n", cell(dep.Name), source, strings.Join(outputs, ", "))
+			}
+		}
+
+		if len(d.Hooks) > 0 {
+			buf.WriteString("\n#### Hooks\n\n")
+			buf.WriteString("| Name | Trigger on | Command |\n")
+			buf.WriteString("|------|------------|---------|\n")
+
+			for _, hook := range d.Hooks {
+				fmt.Fprintf(&buf, "| %s | %s | %s |\n", cell(hook.Name), cell(hook.TriggerOn), code(hook.Command))
+			}
+		}
+
+		if len(d.Inputs) > 0 {
+			buf.WriteString("\n#### Inputs\n\n")
+			buf.WriteString("| Name | Value |\n")
+			buf.WriteString("|------|-------|\n")
+
+			for _, input := range d.Inputs {
+				value := code(input.Value)
+				if input.Sensitive {
+					value = "*sensitive*"
+				}
+
+				fmt.Fprintf(&buf, "| %s | %s |\n", cell(input.Name), value)
+			}
+		}
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// cell escapes text so it can be used in a markdown table
+func cell(text string) string {
+	return strings.Replace(text, "|", "\\|", -1)
+}
+
+// code formats text as inline code in a markdown table, or returns empty string if text is empty
+func code(text string) string {
+	if text == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("`%s`", cell(text))
+}
+
+// anchor returns the anchor github generates for a heading with name
+func anchor(name string) string {
+	name = strings.ToLower(name)
+
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-', r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+
+	return b.String()
+}
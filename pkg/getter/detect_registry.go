@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/hashicorp/terraform/registry"
 	"github.com/hashicorp/terraform/registry/regsrc"
@@ -12,8 +13,14 @@ import (
 // RegistryDetector implements detector to detect terraform registry.
 // Src have to be formatted with query parameter ?registryVersion=
 // avinor/storage-account/azurerm?registryVersion=1.0
+//
+// Download locations are memoised, so the registry is only queried once for each module
+// and version, even if many deployments use same module.
 type RegistryDetector struct {
 	httpClient *http.Client
+
+	locations map[string]string
+	lock      sync.Mutex
 }
 
 // Detect implements the Detector interface and will check if this source is a terraform registry
@@ -37,6 +44,13 @@ func (d *RegistryDetector) DetectRegistry(src string) (string, bool, error) {
 		return "", false, fmt.Errorf("source not a valid registry path")
 	}
 
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if location, ok := d.locations[src]; ok {
+		return location, true, nil
+	}
+
 	pkg := parts[0]
 	version := parts[1]
 
@@ -51,5 +65,10 @@ func (d *RegistryDetector) DetectRegistry(src string) (string, bool, error) {
 		return "", false, err
 	}
 
+	if d.locations == nil {
+		d.locations = map[string]string{}
+	}
+	d.locations[src] = location
+
 	return location, true, nil
 }
@@ -0,0 +1,169 @@
+package getter
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/otiai10/copy"
+
+	"github.com/avinor/tau/pkg/helpers/lock"
+	"github.com/avinor/tau/pkg/helpers/paths"
+	pstrings "github.com/avinor/tau/pkg/helpers/strings"
+	"github.com/avinor/tau/pkg/helpers/ui"
+)
+
+const (
+	// ModulesDirName is name of directory in cache directory where modules are stored
+	ModulesDirName = "modules"
+)
+
+var (
+	// entryLockTimeout is how long to wait for another process downloading the same module
+	entryLockTimeout = 10 * time.Minute
+)
+
+// ModuleCache stores downloaded modules in a directory named by hash of source, including
+// version, so deployments using same module only download it once. Modules are copied from
+// cache into the module directory of each deployment.
+//
+// Local sources are not cached, they are always copied from source so changes are picked up.
+// Sources that can change without changing source string, like a git branch, are cached
+// until refreshed. When Refresh is set every module is downloaded again, but only once for
+// each source while the cache is in use.
+//
+// Cache can be shared by several tau processes, so each cached module is protected by a lock
+// file while it is downloaded and copied. Clear locks the entire cache.
+type ModuleCache struct {
+	dir     string
+	client  *Client
+	refresh bool
+
+	refreshed map[string]bool
+	lock      sync.Mutex
+}
+
+// NewModuleCache returns a module cache storing modules in dir, downloading them with client
+func NewModuleCache(dir string, client *Client, refresh bool) *ModuleCache {
+	return &ModuleCache{
+		dir:       dir,
+		client:    client,
+		refresh:   refresh,
+		refreshed: map[string]bool{},
+	}
+}
+
+// Get copies the module at src into dst, downloading it to cache first if it is not cached
+func (c *ModuleCache) Get(src, dst string) error {
+	detected, err := c.client.Detect(src)
+	if err != nil {
+		return err
+	}
+
+	if strings.HasPrefix(detected, "file:") {
+		return c.client.Get(src, dst)
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	dir := c.Dir(src)
+
+	entryLock := lock.New(dir+lock.Extension, filepath.Join(c.dir, lock.FileName))
+	if err := entryLock.Acquire("module cache", entryLockTimeout); err != nil {
+		return err
+	}
+	defer entryLock.Release()
+
+	if !paths.IsDir(dir) || (c.refresh && !c.refreshed[dir]) {
+		ui.Debug("downloading module %s to cache %s", src, dir)
+
+		if err := c.download(src, dir); err != nil {
+			return err
+		}
+
+		c.refreshed[dir] = true
+	} else {
+		ui.Debug("using module %s from cache %s", src, dir)
+	}
+
+	if err := os.RemoveAll(dst); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	return copy.Copy(dir, dst)
+}
+
+// Dir returns the directory module with src is cached in
+func (c *ModuleCache) Dir(src string) string {
+	return filepath.Join(c.dir, pstrings.Hash(src))
+}
+
+// Clear removes all cached modules. Returns a LockedError if another process is using the cache.
+func (c *ModuleCache) Clear() error {
+	if !paths.IsDir(c.dir) {
+		return nil
+	}
+
+	cacheLock := lock.New(filepath.Join(c.dir, lock.FileName), filepath.Join(c.dir, "*"+lock.Extension))
+	if err := cacheLock.Acquire("clean", 0); err != nil {
+		return err
+	}
+	defer cacheLock.Release()
+
+	entries, err := ioutil.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.Name() == lock.FileName {
+			continue
+		}
+
+		if err := os.RemoveAll(filepath.Join(c.dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// download downloads module to a temporary directory and moves it to dir when completed,
+// so an interrupted download does not leave an incomplete module in cache. Lock for dir
+// has to be held while downloading.
+func (c *ModuleCache) download(src, dir string) error {
+	tmp := fmt.Sprintf("%s.%d.tmp", dir, os.Getpid())
+	defer os.RemoveAll(tmp)
+
+	if err := os.RemoveAll(tmp); err != nil {
+		return err
+	}
+
+	if err := c.client.Get(src, tmp); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, dir); err != nil {
+		// Module is in cache, even if it was not this download that put it there
+		if paths.IsDir(dir) {
+			return nil
+		}
+
+		return err
+	}
+
+	return nil
+}
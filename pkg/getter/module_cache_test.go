@@ -0,0 +1,196 @@
+package getter
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/avinor/tau/pkg/helpers/lock"
+)
+
+// newModuleServer returns a server serving a zip archive with a module, and a counter
+// of number of downloads
+func newModuleServer(t *testing.T) (*httptest.Server, *int32) {
+	var buf bytes.Buffer
+
+	w := zip.NewWriter(&buf)
+	f, err := w.Create("main.tf")
+	assert.NoError(t, err)
+	_, err = f.Write([]byte("variable \"name\" {}\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	var downloads int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			atomic.AddInt32(&downloads, 1)
+		}
+
+		rw.Write(buf.Bytes())
+	}))
+
+	return server, &downloads
+}
+
+func TestModuleCacheGet(t *testing.T) {
+	server, downloads := newModuleServer(t)
+	defer server.Close()
+
+	tests := []struct {
+		Refresh   bool
+		Gets      int
+		Downloads int32
+	}{
+		{false, 1, 1},
+		{false, 3, 1},
+		{true, 3, 2},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "tau-module-cache")
+			assert.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			src := fmt.Sprintf("%s/module%02d.zip", server.URL, i)
+			atomic.StoreInt32(downloads, 0)
+
+			// Module is already cached when refreshing
+			if test.Refresh {
+				cache := NewModuleCache(filepath.Join(dir, "cache"), New(nil), false)
+				assert.NoError(t, cache.Get(src, filepath.Join(dir, "warmup")))
+			}
+
+			cache := NewModuleCache(filepath.Join(dir, "cache"), New(nil), test.Refresh)
+
+			for j := 0; j < test.Gets; j++ {
+				dst := filepath.Join(dir, fmt.Sprintf("deployment%02d", j), "module")
+
+				assert.NoError(t, cache.Get(src, dst))
+				assert.FileExists(t, filepath.Join(dst, "main.tf"))
+			}
+
+			assert.Equal(t, test.Downloads, atomic.LoadInt32(downloads))
+			assert.DirExists(t, cache.Dir(src))
+		})
+	}
+}
+
+func TestModuleCacheShared(t *testing.T) {
+	server, downloads := newModuleServer(t)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "tau-module-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	src := fmt.Sprintf("%s/module.zip", server.URL)
+
+	// Each cache is a separate tau process sharing the cache directory
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			cache := NewModuleCache(filepath.Join(dir, "cache"), New(nil), false)
+			dst := filepath.Join(dir, fmt.Sprintf("deployment%02d", i), "module")
+
+			assert.NoError(t, cache.Get(src, dst))
+			assert.FileExists(t, filepath.Join(dst, "main.tf"))
+		}(i)
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(downloads))
+}
+
+func TestModuleCacheLocks(t *testing.T) {
+	server, _ := newModuleServer(t)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "tau-module-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	defer func(timeout time.Duration) { entryLockTimeout = timeout }(entryLockTimeout)
+	entryLockTimeout = 0
+
+	src := fmt.Sprintf("%s/module.zip", server.URL)
+	cache := NewModuleCache(filepath.Join(dir, "cache"), New(nil), false)
+	assert.NoError(t, cache.Get(src, filepath.Join(dir, "deployment", "module")))
+
+	// Cache cannot be cleared while a module is used by another process
+	entryLock := lock.New(cache.Dir(src) + lock.Extension)
+	assert.NoError(t, entryLock.Acquire("init", 0))
+
+	err = cache.Clear()
+	assert.IsType(t, &lock.LockedError{}, err)
+	assert.DirExists(t, cache.Dir(src))
+	assert.NoError(t, entryLock.Release())
+
+	// Modules cannot be used while cache is cleared by another process
+	cacheLock := lock.New(filepath.Join(dir, "cache", lock.FileName))
+	assert.NoError(t, cacheLock.Acquire("clean", 0))
+
+	err = cache.Get(src, filepath.Join(dir, "deployment", "module"))
+	assert.IsType(t, &lock.LockedError{}, err)
+	assert.NoError(t, cacheLock.Release())
+
+	assert.NoError(t, cache.Clear())
+	assert.NoDirExists(t, cache.Dir(src))
+	assert.NoFileExists(t, filepath.Join(dir, "cache", lock.FileName))
+}
+
+func TestModuleCacheLocalSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tau-module-cache")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "module")
+	assert.NoError(t, os.MkdirAll(src, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(src, "main.tf"), []byte("# v1\n"), 0644))
+
+	cache := NewModuleCache(filepath.Join(dir, "cache"), New(nil), false)
+	dst := filepath.Join(dir, "deployment", "module")
+
+	assert.NoError(t, cache.Get(src, dst))
+
+	// Changes to local modules are copied, they are not cached
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(src, "main.tf"), []byte("# v2\n"), 0644))
+	assert.NoError(t, cache.Get(src, dst))
+
+	content, err := ioutil.ReadFile(filepath.Join(dst, "main.tf"))
+	assert.NoError(t, err)
+	assert.Equal(t, "# v2\n", string(content))
+	assert.NoDirExists(t, cache.Dir(src))
+}
+
+func TestRegistryDetectorMemoised(t *testing.T) {
+	src := "avinor/vnet/azurerm?registryVersion=1.0.0"
+
+	detector := &RegistryDetector{
+		locations: map[string]string{
+			src: "git::https://github.com/avinor/terraform-azurerm-vnet?ref=v1.0.0",
+		},
+	}
+
+	location, ok, err := detector.Detect(src, "")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "git::https://github.com/avinor/terraform-azurerm-vnet?ref=v1.0.0", location)
+}
@@ -0,0 +1,3 @@
+// Package lock implements advisory locks using lock files, to prevent multiple tau processes
+// from changing same temporary directory at the same time.
+package lock
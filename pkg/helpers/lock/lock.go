@@ -0,0 +1,206 @@
+package lock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/avinor/tau/pkg/helpers/paths"
+	"github.com/avinor/tau/pkg/helpers/ui"
+)
+
+const (
+	// FileName is name of lock file for an entire directory
+	FileName = "tau.lock"
+
+	// Extension is extension added to lock files
+	Extension = ".lock"
+)
+
+var (
+	// pollInterval is how often it checks if lock has been released when waiting for lock
+	pollInterval = 500 * time.Millisecond
+)
+
+// Info describes the process holding a lock
+type Info struct {
+	PID     int       `json:"pid"`
+	Host    string    `json:"host"`
+	Command string    `json:"command"`
+	Created time.Time `json:"created"`
+}
+
+// String returns a description of the lock holder
+func (i *Info) String() string {
+	return fmt.Sprintf("tau %s (pid %v on %s) since %s", i.Command, i.PID, i.Host, i.Created.Format(time.RFC3339))
+}
+
+// LockedError is returned when lock is held by another process
+type LockedError struct {
+	Path string
+	Info *Info
+}
+
+// Error returns the error message
+func (e *LockedError) Error() string {
+	holder := "another process"
+	if e.Info != nil {
+		holder = e.Info.String()
+	}
+
+	return fmt.Sprintf("%s is locked by %s. If lock is stale run tau unlock", e.Path, holder)
+}
+
+// Lock is an advisory lock using a lock file. Lock is acquired by creating the lock file,
+// which fails if it already exists. File contains information about process holding the
+// lock, so a stale lock can be identified and removed.
+//
+// Conflicts are glob patterns of other lock files that cannot be held at same time, for instance
+// the locks of deployments when locking entire directory. Lock is not acquired if any of them
+// are held.
+type Lock struct {
+	Path      string
+	Conflicts []string
+
+	held bool
+}
+
+// New returns a lock using lock file path, that cannot be acquired while any lock file
+// matching conflicts is held
+func New(path string, conflicts ...string) *Lock {
+	return &Lock{
+		Path:      path,
+		Conflicts: conflicts,
+	}
+}
+
+// Acquire the lock. If lock is held by another process it waits up to timeout for it to
+// be released. Returns a LockedError if it was not released within timeout.
+func (l *Lock) Acquire(command string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	waiting := false
+
+	for {
+		err := l.tryAcquire(command)
+		if err == nil {
+			return nil
+		}
+
+		if _, ok := err.(*LockedError); !ok || time.Now().After(deadline) {
+			return err
+		}
+
+		if !waiting {
+			ui.Info("- Waiting for lock: %s", err)
+			waiting = true
+		}
+
+		time.Sleep(pollInterval)
+	}
+}
+
+// Release the lock if it is held
+func (l *Lock) Release() error {
+	if !l.held {
+		return nil
+	}
+
+	l.held = false
+
+	return os.Remove(l.Path)
+}
+
+// tryAcquire tries to create lock file, returns LockedError if it already exists
+func (l *Lock) tryAcquire(command string) error {
+	paths.EnsureDirectoryExists(filepath.Dir(l.Path))
+
+	f, err := os.OpenFile(l.Path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		if !os.IsExist(err) {
+			return err
+		}
+
+		info, _ := Read(l.Path)
+
+		return &LockedError{
+			Path: l.Path,
+			Info: info,
+		}
+	}
+	defer f.Close()
+
+	host, _ := os.Hostname()
+
+	content, err := json.Marshal(&Info{
+		PID:     os.Getpid(),
+		Host:    host,
+		Command: command,
+		Created: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(content); err != nil {
+		return err
+	}
+
+	// Conflicting locks are checked after creating lock file, so two processes acquiring
+	// conflicting locks at same time cannot both succeed
+	conflict, err := l.heldConflict()
+	if err != nil || conflict != "" {
+		f.Close()
+		os.Remove(l.Path)
+
+		if err != nil {
+			return err
+		}
+
+		info, _ := Read(conflict)
+		return &LockedError{
+			Path: conflict,
+			Info: info,
+		}
+	}
+
+	l.held = true
+
+	return nil
+}
+
+// heldConflict returns path of first conflicting lock file that is held, or empty string
+// if none of them are held
+func (l *Lock) heldConflict() (string, error) {
+	for _, pattern := range l.Conflicts {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return "", err
+		}
+
+		for _, match := range matches {
+			if match != l.Path {
+				return match, nil
+			}
+		}
+	}
+
+	return "", nil
+}
+
+// Read returns information about process holding lock in lock file path
+func Read(path string) (*Info, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &Info{}
+	if err := json.Unmarshal(content, info); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
@@ -0,0 +1,92 @@
+package lock
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tau-lock")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, FileName)
+
+	first := New(path)
+	second := New(path)
+
+	assert.NoError(t, first.Acquire("plan", 0))
+
+	info, err := Read(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "plan", info.Command)
+	assert.Equal(t, os.Getpid(), info.PID)
+
+	err = second.Acquire("apply", 0)
+	assert.IsType(t, &LockedError{}, err)
+	assert.Equal(t, "plan", err.(*LockedError).Info.Command)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		first.Release()
+	}()
+
+	assert.NoError(t, second.Acquire("apply", 5*time.Second))
+	assert.NoError(t, second.Release())
+	assert.NoError(t, second.Release())
+
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestLockConflicts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tau-lock")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dirLock := filepath.Join(dir, FileName)
+	deploymentLocks := filepath.Join(dir, "*"+Extension)
+
+	directory := New(dirLock, deploymentLocks)
+	vnet := New(filepath.Join(dir, "vnet.hcl"+Extension), dirLock)
+	aks := New(filepath.Join(dir, "aks.hcl"+Extension), dirLock)
+
+	// Deployments can be locked at same time, but not directory
+	assert.NoError(t, vnet.Acquire("plan", 0))
+	assert.NoError(t, aks.Acquire("plan", 0))
+
+	err = directory.Acquire("init", 0)
+	assert.IsType(t, &LockedError{}, err)
+	assert.Equal(t, "plan", err.(*LockedError).Info.Command)
+
+	_, err = os.Stat(dirLock)
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NoError(t, vnet.Release())
+	assert.IsType(t, &LockedError{}, directory.Acquire("init", 0))
+
+	// Directory is locked once all deployments are released
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		aks.Release()
+	}()
+
+	assert.NoError(t, directory.Acquire("init", 5*time.Second))
+
+	// Deployments cannot be locked while directory is locked
+	err = vnet.Acquire("plan", 0)
+	assert.IsType(t, &LockedError{}, err)
+	assert.Equal(t, dirLock, err.(*LockedError).Path)
+
+	_, err = os.Stat(filepath.Join(dir, "vnet.hcl"+Extension))
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NoError(t, directory.Release())
+	assert.NoError(t, vnet.Acquire("plan", 0))
+	assert.NoError(t, vnet.Release())
+}
@@ -1,11 +1,30 @@
 package strings
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
 )
 
 var (
 	outputRegexp = regexp.MustCompile("(?m:^\\s*\"?([^\"=\\s]*)\"?\\s*=\\s*\"?([^\"\\n]*)\"?$)")
+
+	// envNameRegexp validates environment variable names
+	envNameRegexp = regexp.MustCompile("^[a-zA-Z_][a-zA-Z0-9_]*$")
+
+	// jsonPathRegexp matches a single part of a json path, either .key or [index]
+	jsonPathRegexp = regexp.MustCompile(`^(?:\.?([^.\[\]]+)|\[(\d+)\])`)
+
+	// jsonNotObject is returned if json value at path is not an object
+	jsonNotObject = errors.Errorf("json value is not an object")
+
+	// unterminatedQuote is returned if a quoted value is not terminated
+	unterminatedQuote = errors.Errorf("unterminated quoted value")
 )
 
 // ParseVars parses each line as key=value and returns a map of all variables.
@@ -28,3 +47,291 @@ func ParseVars(output string) map[string]string {
 
 	return values
 }
+
+// ParseJSONVars parses output as a json object and returns all attributes as variables.
+// Path can be used to select a nested object with a jq like syntax (.data.keys[0]).
+// Strings are used as is, while objects and lists are returned as json strings.
+func ParseJSONVars(output, path string) (map[string]string, error) {
+	var value interface{}
+
+	decoder := json.NewDecoder(strings.NewReader(output))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+
+	value, err := selectJSONPath(value, path)
+	if err != nil {
+		return nil, err
+	}
+
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, jsonNotObject
+	}
+
+	values := map[string]string{}
+	for key, item := range object {
+		switch v := item.(type) {
+		case nil:
+			values[key] = ""
+		case string:
+			values[key] = v
+		case json.Number:
+			values[key] = v.String()
+		case bool:
+			values[key] = strconv.FormatBool(v)
+		default:
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				return nil, err
+			}
+
+			values[key] = string(encoded)
+		}
+	}
+
+	return values, nil
+}
+
+// ParseDotenvVars parses output in dotenv format. Empty lines and lines starting with # are
+// ignored. Values can be unquoted, single quoted (no escapes) or double quoted where escape
+// sequences are supported and value can span multiple lines.
+func ParseDotenvVars(output string) (map[string]string, error) {
+	return parseEnvLines(output, false)
+}
+
+// ParseExportVars parses output as shell export statements (export KEY=value). Values are
+// unquoted according to shell rules, so output from `export -p` or `declare -x` is supported.
+func ParseExportVars(output string) (map[string]string, error) {
+	return parseEnvLines(output, true)
+}
+
+// selectJSONPath returns the value at path in value. An empty path or "." returns value.
+func selectJSONPath(value interface{}, path string) (interface{}, error) {
+	rest := strings.TrimSpace(path)
+
+	for rest != "" && rest != "." {
+		match := jsonPathRegexp.FindStringSubmatch(rest)
+		if match == nil {
+			return nil, errors.Errorf("invalid json path %q", path)
+		}
+
+		rest = rest[len(match[0]):]
+
+		if match[2] != "" {
+			list, ok := value.([]interface{})
+			if !ok {
+				return nil, errors.Errorf("json path %q: value is not a list", path)
+			}
+
+			idx, _ := strconv.Atoi(match[2])
+			if idx >= len(list) {
+				return nil, errors.Errorf("json path %q: index %v out of range", path, idx)
+			}
+
+			value = list[idx]
+			continue
+		}
+
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, errors.Errorf("json path %q: value is not an object", path)
+		}
+
+		if value, ok = object[match[1]]; !ok {
+			return nil, errors.Errorf("json path %q: key %q not found", path, match[1])
+		}
+	}
+
+	return value, nil
+}
+
+// parseEnvLines parses all lines as KEY=value. If shell is set values are parsed as shell
+// words and lines must start with export (or declare -x), otherwise dotenv rules are used.
+func parseEnvLines(output string, shell bool) (map[string]string, error) {
+	values := map[string]string{}
+	lines := strings.Split(strings.Replace(output, "\r\n", "\n", -1), "\n")
+
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimSpace(lines[i])
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		hasExport := false
+		for _, prefix := range []string{"export ", "declare -x "} {
+			if strings.HasPrefix(line, prefix) {
+				line = strings.TrimSpace(line[len(prefix):])
+				hasExport = true
+			}
+		}
+
+		if shell && !hasExport {
+			return nil, errors.Errorf("line %v: expected export statement", lineNo)
+		}
+
+		split := strings.SplitN(line, "=", 2)
+		key := strings.TrimSpace(split[0])
+
+		if !envNameRegexp.MatchString(key) {
+			return nil, errors.Errorf("line %v: invalid variable name %q", lineNo, key)
+		}
+
+		if len(split) < 2 {
+			return nil, errors.Errorf("line %v: missing value for %s", lineNo, key)
+		}
+
+		value := split[1]
+		if !shell {
+			value = strings.TrimSpace(value)
+		}
+
+		// Quoted values can span multiple lines, keep adding lines until value is complete
+		for {
+			var parsed string
+			var err error
+
+			if shell {
+				parsed, err = parseShellWord(value)
+			} else {
+				parsed, err = parseDotenvValue(value)
+			}
+
+			if err == nil {
+				values[key] = parsed
+				break
+			}
+
+			if err != unterminatedQuote || i+1 >= len(lines) {
+				return nil, errors.Errorf("line %v: %s", lineNo, err)
+			}
+
+			i++
+			value = fmt.Sprintf("%s\n%s", value, lines[i])
+		}
+	}
+
+	return values, nil
+}
+
+// parseDotenvValue parses a single dotenv value
+func parseDotenvValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	switch value[0] {
+	case '\'':
+		end := strings.Index(value[1:], "'")
+		if end < 0 {
+			return "", unterminatedQuote
+		}
+
+		return value[1 : end+1], checkTrailing(value[end+2:])
+	case '"':
+		var buf bytes.Buffer
+
+		for i := 1; i < len(value); i++ {
+			c := value[i]
+
+			switch {
+			case c == '\\' && i+1 < len(value):
+				i++
+				buf.WriteByte(unescape(value[i]))
+			case c == '"':
+				return buf.String(), checkTrailing(value[i+1:])
+			default:
+				buf.WriteByte(c)
+			}
+		}
+
+		return "", unterminatedQuote
+	}
+
+	if idx := strings.Index(value, " #"); idx >= 0 {
+		value = value[:idx]
+	}
+
+	return strings.TrimSpace(value), nil
+}
+
+// parseShellWord parses value as a single shell word, concatenating quoted and unquoted parts
+func parseShellWord(value string) (string, error) {
+	var buf bytes.Buffer
+
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+
+		switch c {
+		case '\'':
+			end := strings.Index(value[i+1:], "'")
+			if end < 0 {
+				return "", unterminatedQuote
+			}
+
+			buf.WriteString(value[i+1 : i+1+end])
+			i += end + 1
+		case '"':
+			closed := false
+
+			for i++; i < len(value); i++ {
+				if value[i] == '\\' && i+1 < len(value) && strings.IndexByte("\"\\$`\n", value[i+1]) >= 0 {
+					i++
+					buf.WriteByte(value[i])
+					continue
+				}
+
+				if value[i] == '"' {
+					closed = true
+					break
+				}
+
+				buf.WriteByte(value[i])
+			}
+
+			if !closed {
+				return "", unterminatedQuote
+			}
+		case '\\':
+			if i+1 < len(value) {
+				i++
+				buf.WriteByte(value[i])
+			}
+		case ' ', '\t':
+			return buf.String(), checkTrailing(value[i:])
+		default:
+			buf.WriteByte(c)
+		}
+	}
+
+	return buf.String(), nil
+}
+
+// checkTrailing returns an error if there is anything else than a comment after value
+func checkTrailing(rest string) error {
+	rest = strings.TrimSpace(rest)
+
+	if rest == "" || strings.HasPrefix(rest, "#") {
+		return nil
+	}
+
+	return errors.Errorf("unexpected characters after value: %s", rest)
+}
+
+// unescape returns the character for escape sequence \c
+func unescape(c byte) byte {
+	switch c {
+	case 'n':
+		return '\n'
+	case 'r':
+		return '\r'
+	case 't':
+		return '\t'
+	default:
+		return c
+	}
+}
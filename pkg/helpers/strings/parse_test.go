@@ -62,3 +62,101 @@ test=success
 		})
 	}
 }
+
+func TestParseJSONVars(t *testing.T) {
+	tests := []struct {
+		output  string
+		path    string
+		expects map[string]string
+		err     bool
+	}{
+		{`{"key": "value", "num": 5, "bool": true}`, "", map[string]string{"key": "value", "num": "5", "bool": "true"}, false},
+		{`{"key": "with \"quotes\" and = sign"}`, "", map[string]string{"key": `with "quotes" and = sign`}, false},
+		{`{"key": "line1\nline2"}`, ".", map[string]string{"key": "line1\nline2"}, false},
+		{`{"data": {"keys": [{"key": "value"}]}}`, ".data.keys[0]", map[string]string{"key": "value"}, false},
+		{`{"data": {"keys": [{"key": "value"}]}}`, "data.keys[0]", map[string]string{"key": "value"}, false},
+		{`{"data": {"list": [1, 2]}}`, ".data", map[string]string{"list": "[1,2]"}, false},
+		{`{"data": {}}`, ".missing", nil, true},
+		{`["value"]`, "", nil, true},
+		{`not json`, "", nil, true},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			values, err := ParseJSONVars(test.output, test.path)
+
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expects, values)
+		})
+	}
+}
+
+func TestParseDotenvVars(t *testing.T) {
+	tests := []struct {
+		output  string
+		expects map[string]string
+		err     bool
+	}{
+		{"KEY=value", map[string]string{"KEY": "value"}, false},
+		{`
+			# comment
+			KEY=value # comment
+			export OTHER = "quoted = value"
+		`, map[string]string{"KEY": "value", "OTHER": "quoted = value"}, false},
+		{`KEY="line1\nline2 \"quoted\""`, map[string]string{"KEY": "line1\nline2 \"quoted\""}, false},
+		{`KEY='literal \n $value'`, map[string]string{"KEY": `literal \n $value`}, false},
+		{"KEY=\"multi\nline\"\nOTHER=value", map[string]string{"KEY": "multi\nline", "OTHER": "value"}, false},
+		{`KEY="unterminated`, nil, true},
+		{`invalid-name=value`, nil, true},
+		{`KEY`, nil, true},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			values, err := ParseDotenvVars(test.output)
+
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expects, values)
+		})
+	}
+}
+
+func TestParseExportVars(t *testing.T) {
+	tests := []struct {
+		output  string
+		expects map[string]string
+		err     bool
+	}{
+		{"export KEY=value", map[string]string{"KEY": "value"}, false},
+		{`export KEY='it'\''s = "quoted"'`, map[string]string{"KEY": `it's = "quoted"`}, false},
+		{`declare -x KEY="with \"escapes\" and \$dollar"`, map[string]string{"KEY": `with "escapes" and $dollar`}, false},
+		{"export KEY=\"multi\nline\"", map[string]string{"KEY": "multi\nline"}, false},
+		{`export KEY=with\ space`, map[string]string{"KEY": "with space"}, false},
+		{"KEY=value", nil, true},
+		{"export KEY=two words", nil, true},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			values, err := ParseExportVars(test.output)
+
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expects, values)
+		})
+	}
+}
@@ -21,6 +21,9 @@ type CliHandler struct {
 	LogWriter    io.Writer
 
 	previousLine string
+
+	// bufReader reads from Reader, kept between questions so buffered input is not lost
+	bufReader *bufio.Reader
 }
 
 // Ask user to input
@@ -116,6 +119,10 @@ func (hnd *CliHandler) ask(query string, secret bool) (string, error) {
 	signal.Notify(sigCh, os.Interrupt)
 	defer signal.Stop(sigCh)
 
+	if hnd.bufReader == nil {
+		hnd.bufReader = bufio.NewReader(hnd.Reader)
+	}
+
 	// Ask for input in a go-routine so that we can ignore it.
 	errCh := make(chan error, 1)
 	lineCh := make(chan string, 1)
@@ -125,8 +132,7 @@ func (hnd *CliHandler) ask(query string, secret bool) (string, error) {
 		if secret && isatty.IsTerminal(os.Stdin.Fd()) {
 			line, err = speakeasy.Ask("")
 		} else {
-			r := bufio.NewReader(hnd.Reader)
-			line, err = r.ReadString('\n')
+			line, err = hnd.bufReader.ReadString('\n')
 		}
 		if err != nil {
 			errCh <- err
@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"sync"
+)
+
+const (
+	// CommandContext is the tau command running
+	CommandContext = "command"
+
+	// DeploymentContext is name of deployment currently processed
+	DeploymentContext = "deployment"
+
+	// PhaseContext is current phase of processing a deployment, for instance hooks or init
+	PhaseContext = "phase"
+
+	// TerraformContext is the terraform command currently executing
+	TerraformContext = "terraform"
+)
+
+var (
+	// context describes what is currently processed, handlers can add it to messages
+	context = map[string]string{}
+
+	contextLock sync.RWMutex
+)
+
+// WithContext sets value for key in context and returns a function that restores
+// the previous value. Use together with defer to set context for a function:
+//
+//	defer ui.WithContext(ui.PhaseContext, "init")()
+func WithContext(key, value string) func() {
+	contextLock.Lock()
+	defer contextLock.Unlock()
+
+	previous, exists := context[key]
+	context[key] = value
+
+	return func() {
+		contextLock.Lock()
+		defer contextLock.Unlock()
+
+		if exists {
+			context[key] = previous
+		} else {
+			delete(context, key)
+		}
+	}
+}
+
+// Context returns a copy of current context
+func Context() map[string]string {
+	contextLock.RLock()
+	defer contextLock.RUnlock()
+
+	ctx := make(map[string]string, len(context))
+	for key, value := range context {
+		ctx[key] = value
+	}
+
+	return ctx
+}
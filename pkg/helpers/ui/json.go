@@ -0,0 +1,118 @@
+package ui
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// colorRegexp matches ansi color codes, which are removed from json messages
+	colorRegexp = regexp.MustCompile("\x1b\\[[0-9;]*m")
+)
+
+// JSONHandler writes all log messages as one json object per line, including level and
+// current context, so output can be parsed by log aggregation tools. Output is written
+// unchanged to output writer.
+type JSONHandler struct {
+	*CliHandler
+
+	lock sync.Mutex
+}
+
+// jsonMessage is the json object written for every message
+type jsonMessage struct {
+	Time       string `json:"time"`
+	Level      string `json:"level"`
+	Command    string `json:"command,omitempty"`
+	Deployment string `json:"deployment,omitempty"`
+	Phase      string `json:"phase,omitempty"`
+	Terraform  string `json:"terraform,omitempty"`
+	Message    string `json:"message"`
+}
+
+// NewJSONHandler returns a json handler reading input from reader, writing output to
+// outputWriter and log messages to logWriter
+func NewJSONHandler(reader io.Reader, outputWriter, logWriter io.Writer) *JSONHandler {
+	return &JSONHandler{
+		CliHandler: &CliHandler{
+			Reader:       reader,
+			OutputWriter: outputWriter,
+			LogWriter:    logWriter,
+		},
+	}
+}
+
+// Debug prints a debug message
+func (hnd *JSONHandler) Debug(msg string, args ...interface{}) {
+	hnd.write("debug", msg, args...)
+}
+
+// Info prints an information message
+func (hnd *JSONHandler) Info(msg string, args ...interface{}) {
+	hnd.write("info", msg, args...)
+}
+
+// Warn prints a warning
+func (hnd *JSONHandler) Warn(msg string, args ...interface{}) {
+	hnd.write("warn", msg, args...)
+}
+
+// Error prints an error message
+func (hnd *JSONHandler) Error(msg string, args ...interface{}) {
+	hnd.write("error", msg, args...)
+}
+
+// Fatal prints message and exits
+func (hnd *JSONHandler) Fatal(msg string, args ...interface{}) {
+	hnd.write("fatal", msg, args...)
+	os.Exit(1)
+}
+
+// Header prints header as an info message
+func (hnd *JSONHandler) Header(msg string) {
+	hnd.write("info", msg)
+}
+
+// Separator does nothing, deployment is included in context
+func (hnd *JSONHandler) Separator(title string) {}
+
+// NewLine does nothing
+func (hnd *JSONHandler) NewLine() {}
+
+// write message as json with current context. Empty messages are ignored
+func (hnd *JSONHandler) write(level, msg string, args ...interface{}) {
+	if len(args) > 0 {
+		msg = fmt.Sprintf(msg, args...)
+	}
+
+	msg = strings.TrimSpace(colorRegexp.ReplaceAllString(msg, ""))
+	if msg == "" {
+		return
+	}
+
+	ctx := Context()
+
+	line, err := json.Marshal(&jsonMessage{
+		Time:       time.Now().UTC().Format(time.RFC3339),
+		Level:      level,
+		Command:    ctx[CommandContext],
+		Deployment: ctx[DeploymentContext],
+		Phase:      ctx[PhaseContext],
+		Terraform:  ctx[TerraformContext],
+		Message:    msg,
+	})
+	if err != nil {
+		return
+	}
+
+	hnd.lock.Lock()
+	defer hnd.lock.Unlock()
+
+	fmt.Fprintln(hnd.LogWriter, string(line))
+}
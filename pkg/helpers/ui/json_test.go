@@ -0,0 +1,89 @@
+package ui
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONHandler(t *testing.T) {
+	tests := []struct {
+		write   func(hnd *JSONHandler)
+		context map[string]string
+		expects map[string]interface{}
+	}{
+		{
+			func(hnd *JSONHandler) { hnd.Info("hello %s", "world") },
+			map[string]string{},
+			map[string]interface{}{"level": "info", "message": "hello world"},
+		},
+		{
+			func(hnd *JSONHandler) { hnd.Warn("\x1b[33mwarning\x1b[0m") },
+			map[string]string{DeploymentContext: "vnet.hcl", PhaseContext: "hooks"},
+			map[string]interface{}{"level": "warn", "message": "warning", "deployment": "vnet.hcl", "phase": "hooks"},
+		},
+		{
+			func(hnd *JSONHandler) { hnd.Header("Resolving dependencies...") },
+			map[string]string{CommandContext: "plan", TerraformContext: "init"},
+			map[string]interface{}{"level": "info", "message": "Resolving dependencies...", "command": "plan", "terraform": "init"},
+		},
+		{
+			func(hnd *JSONHandler) {
+				hnd.NewLine()
+				hnd.Separator("vnet.hcl")
+				hnd.Info("")
+			},
+			map[string]string{},
+			nil,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			restore := []func(){}
+			for key, value := range test.context {
+				restore = append(restore, WithContext(key, value))
+			}
+
+			logs := &bytes.Buffer{}
+			hnd := NewJSONHandler(strings.NewReader(""), &bytes.Buffer{}, logs)
+
+			test.write(hnd)
+
+			for _, fn := range restore {
+				fn()
+			}
+
+			if test.expects == nil {
+				assert.Equal(t, "", logs.String())
+				return
+			}
+
+			msg := map[string]interface{}{}
+			assert.NoError(t, json.Unmarshal(logs.Bytes(), &msg))
+			assert.NotEmpty(t, msg["time"])
+
+			delete(msg, "time")
+			assert.Equal(t, test.expects, msg)
+		})
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	restore := WithContext(PhaseContext, "init")
+	assert.Equal(t, "init", Context()[PhaseContext])
+
+	restoreInner := WithContext(PhaseContext, "hooks")
+	assert.Equal(t, "hooks", Context()[PhaseContext])
+
+	restoreInner()
+	assert.Equal(t, "init", Context()[PhaseContext])
+
+	restore()
+	_, exists := Context()[PhaseContext]
+	assert.False(t, exists)
+}
@@ -0,0 +1,280 @@
+package hooks
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-errors/errors"
+
+	"github.com/avinor/tau/pkg/config"
+	pstrings "github.com/avinor/tau/pkg/helpers/strings"
+	"github.com/avinor/tau/pkg/helpers/ui"
+	"github.com/avinor/tau/pkg/hooks/def"
+)
+
+const (
+	// CacheKeyEnv is environment variable that can be set to define the key used to encrypt
+	// hook output cache. If not set it will generate a key stored in user config directory
+	CacheKeyEnv = "TAU_HOOK_CACHE_KEY"
+
+	// CacheDirName is name of directory in cache directory where hook output is stored
+	CacheDirName = "_hooks"
+)
+
+var (
+	// invalidCacheEntry is returned if cache entry could not be decrypted
+	invalidCacheEntry = errors.Errorf("invalid cache entry")
+)
+
+// OutputCache stores output from hooks encrypted on disk so it can be reused between
+// executions until it expires.
+type OutputCache struct {
+	dir string
+
+	keyOnce sync.Once
+	key     []byte
+	keyErr  error
+}
+
+// cacheEntry is the content of an encrypted cache file
+type cacheEntry struct {
+	Expires time.Time `json:"expires"`
+	Output  string    `json:"output"`
+}
+
+// NewOutputCache returns a cache storing entries in dir
+func NewOutputCache(dir string) *OutputCache {
+	return &OutputCache{
+		dir: dir,
+	}
+}
+
+// Get returns the cached output for key. Returns false if there is no valid entry for key,
+// expired entries are deleted.
+func (c *OutputCache) Get(key string) (string, bool) {
+	file := c.path(key)
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", false
+	}
+
+	plain, err := c.decrypt(data)
+	if err != nil {
+		ui.Debug("could not decrypt hook cache %s: %s", file, err)
+		os.Remove(file)
+		return "", false
+	}
+
+	entry := &cacheEntry{}
+	if err := json.Unmarshal(plain, entry); err != nil {
+		os.Remove(file)
+		return "", false
+	}
+
+	if time.Now().After(entry.Expires) {
+		ui.Debug("hook cache %s expired", file)
+		os.Remove(file)
+		return "", false
+	}
+
+	return entry.Output, true
+}
+
+// Put stores output for key, valid for duration ttl
+func (c *OutputCache) Put(key, output string, ttl time.Duration) error {
+	plain, err := json.Marshal(&cacheEntry{
+		Expires: time.Now().Add(ttl),
+		Output:  output,
+	})
+	if err != nil {
+		return err
+	}
+
+	data, err := c.encrypt(plain)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(c.dir, 0700); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(c.path(key), data, 0600)
+}
+
+// Clear removes all cached entries
+func (c *OutputCache) Clear() error {
+	return os.RemoveAll(c.dir)
+}
+
+// path returns the file path for key
+func (c *OutputCache) path(key string) string {
+	return filepath.Join(c.dir, pstrings.Hash(key))
+}
+
+// encrypt data with AES-GCM, nonce is prepended to the result
+func (c *OutputCache) encrypt(data []byte) ([]byte, error) {
+	gcm, err := c.cipher()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := pstrings.SecureRandomBytes(gcm.NonceSize())
+
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// decrypt data encrypted with encrypt
+func (c *OutputCache) decrypt(data []byte) ([]byte, error) {
+	gcm, err := c.cipher()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, invalidCacheEntry
+	}
+
+	nonce := data[:gcm.NonceSize()]
+
+	return gcm.Open(nil, nonce, data[gcm.NonceSize():], nil)
+}
+
+// cipher returns the AES-GCM cipher using cache key
+func (c *OutputCache) cipher() (cipher.AEAD, error) {
+	c.keyOnce.Do(func() {
+		c.key, c.keyErr = loadCacheKey()
+	})
+
+	if c.keyErr != nil {
+		return nil, c.keyErr
+	}
+
+	block, err := aes.NewCipher(c.key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// loadCacheKey returns the key to encrypt cache with. Key is read from CacheKeyEnv if set,
+// otherwise it is read from user config directory, generating a new key if it does not exist.
+// Key is not stored in cache directory so copying cache directory does not expose secrets.
+func loadCacheKey() ([]byte, error) {
+	if env := os.Getenv(CacheKeyEnv); env != "" {
+		key := sha256.Sum256([]byte(env))
+		return key[:], nil
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	file := filepath.Join(configDir, "tau", "hook_cache.key")
+
+	if key, err := ioutil.ReadFile(file); err == nil && len(key) == 32 {
+		return key, nil
+	}
+
+	key := pstrings.SecureRandomBytes(32)
+
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(file, key, 0600); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// cachedExecutor wraps an executor and stores the output in persistent cache
+type cachedExecutor struct {
+	def.Executor
+
+	cache *OutputCache
+	hook  *config.Hook
+
+	output    string
+	fromCache bool
+}
+
+// Output returns output from cache if read from cache, otherwise from executor
+func (e *cachedExecutor) Output() string {
+	if e.fromCache {
+		return e.output
+	}
+
+	return e.Executor.Output()
+}
+
+// HasRun returns true if output has been read from cache or executor has run
+func (e *cachedExecutor) HasRun() bool {
+	return e.fromCache || e.Executor.HasRun()
+}
+
+// Run reads output from cache if it exists, otherwise it runs the executor and stores
+// output in cache.
+func (e *cachedExecutor) Run(ctx context.Context, env map[string]string) error {
+	key := persistentCacheKey(e.hook, env)
+
+	if output, ok := e.cache.Get(key); ok {
+		ui.Debug("using cached output for hook %s", e.hook.Type)
+		e.output = output
+		e.fromCache = true
+		return nil
+	}
+
+	e.fromCache = false
+
+	if err := e.Executor.Run(ctx, env); err != nil {
+		return err
+	}
+
+	if err := e.cache.Put(key, e.Executor.Output(), e.hook.GetCacheTTL()); err != nil {
+		ui.Warn("Could not cache output for hook %s: %s", e.hook.Type, err)
+	}
+
+	return nil
+}
+
+// persistentCacheKey returns the key for hook in persistent cache. It includes the environment
+// variables, except those describing execution context, as they can change the output
+func persistentCacheKey(hook *config.Hook, env map[string]string) string {
+	keys := []string{}
+	for key := range env {
+		if strings.HasPrefix(key, "TAU_") {
+			continue
+		}
+
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteString(getCacheKey(hook))
+
+	for _, key := range keys {
+		sb.WriteString("\n")
+		sb.WriteString(key)
+		sb.WriteString("=")
+		sb.WriteString(env[key])
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,68 @@
+package hooks
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/avinor/tau/pkg/config"
+	"github.com/avinor/tau/pkg/helpers/strings"
+)
+
+func TestOutputCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tau_cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	os.Setenv(CacheKeyEnv, "test-key")
+	defer os.Unsetenv(CacheKeyEnv)
+
+	cache := NewOutputCache(filepath.Join(dir, CacheDirName))
+
+	_, ok := cache.Get("key")
+	assert.False(t, ok)
+
+	assert.NoError(t, cache.Put("key", "SECRET=value", time.Minute))
+	assert.NoError(t, cache.Put("expired", "SECRET=value", -time.Minute))
+
+	output, ok := cache.Get("key")
+	assert.True(t, ok)
+	assert.Equal(t, "SECRET=value", output)
+
+	_, ok = cache.Get("expired")
+	assert.False(t, ok)
+
+	// output should not be stored in plain text
+	files, _ := filepath.Glob(filepath.Join(dir, CacheDirName, "*"))
+	for _, file := range files {
+		content, _ := ioutil.ReadFile(file)
+		assert.NotContains(t, string(content), "SECRET")
+	}
+
+	// entries encrypted with another key are invalid
+	other := NewOutputCache(filepath.Join(dir, CacheDirName))
+	os.Setenv(CacheKeyEnv, "other-key")
+	_, ok = other.Get("key")
+	assert.False(t, ok)
+
+	assert.NoError(t, cache.Clear())
+	_, ok = cache.Get("key")
+	assert.False(t, ok)
+}
+
+func TestPersistentCacheKey(t *testing.T) {
+	hook := &config.Hook{Command: strings.ToPointer("command")}
+
+	key1 := persistentCacheKey(hook, map[string]string{"ARM_SUBSCRIPTION_ID": "1", "TAU_SOURCE_FILE": "a.hcl"})
+	key2 := persistentCacheKey(hook, map[string]string{"ARM_SUBSCRIPTION_ID": "1", "TAU_SOURCE_FILE": "b.hcl"})
+	key3 := persistentCacheKey(hook, map[string]string{"ARM_SUBSCRIPTION_ID": "2"})
+
+	assert.Equal(t, key1, key2)
+	assert.NotEqual(t, key1, key3)
+}
@@ -37,8 +37,10 @@ func (c *Creator) Create(hook *config.Hook) (def.Executor, error) {
 	}
 
 	return &Executor{
-		Command:    command,
-		Arguments:  arguments,
-		WorkingDir: workingDir,
+		Command:         command,
+		Arguments:       arguments,
+		WorkingDir:      workingDir,
+		Timeout:         hook.GetTimeout(),
+		SensitiveOutput: hook.SetEnv != nil && *hook.SetEnv,
 	}, nil
 }
@@ -1,18 +1,23 @@
 package command
 
 import (
+	"context"
 	"sync"
+	"time"
 
 	"github.com/avinor/tau/pkg/helpers/ui"
 	"github.com/avinor/tau/pkg/shell"
 	"github.com/avinor/tau/pkg/shell/processors"
 )
 
-// Executor can execute a command
+// Executor can execute a command. If Timeout is set the command is stopped if it
+// does not complete within timeout. Output is not written to log if SensitiveOutput is set.
 type Executor struct {
-	Command    string
-	Arguments  []string
-	WorkingDir string
+	Command         string
+	Arguments       []string
+	WorkingDir      string
+	Timeout         time.Duration
+	SensitiveOutput bool
 
 	output string
 	hasRun bool
@@ -31,7 +36,7 @@ func (e *Executor) Output() string {
 }
 
 // Run the command and store result in output
-func (e *Executor) Run(env map[string]string) error {
+func (e *Executor) Run(ctx context.Context, env map[string]string) error {
 	e.lock.Lock()
 	defer e.lock.Unlock()
 
@@ -43,6 +48,8 @@ func (e *Executor) Run(env map[string]string) error {
 		Stderr:           shell.Processors(logp),
 		WorkingDirectory: e.WorkingDir,
 		Env:              env,
+		Timeout:          e.Timeout,
+		SensitiveStdout:  e.SensitiveOutput,
 	}
 
 	args := []string{}
@@ -50,7 +57,7 @@ func (e *Executor) Run(env map[string]string) error {
 		args = append(args, e.Arguments...)
 	}
 
-	if err := shell.Execute(options, e.Command, args...); err != nil {
+	if err := shell.Execute(ctx, options, e.Command, args...); err != nil {
 		e.hasRun = true
 		return err
 	}
@@ -1,8 +1,12 @@
 package def
 
+import (
+	"context"
+)
+
 // Executor can execute a hook and return the output from hook execution.
 type Executor interface {
 	HasRun() bool
-	Run(env map[string]string) error
+	Run(ctx context.Context, env map[string]string) error
 	Output() string
 }
@@ -0,0 +1,113 @@
+package hooks
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+
+	"github.com/avinor/tau/pkg/config/loader"
+	"github.com/avinor/tau/pkg/output"
+)
+
+const (
+	// ErrorMessageEnv is environment variable containing the error message when running
+	// error and always hooks after a failure
+	ErrorMessageEnv = "TAU_ERROR_MESSAGE"
+
+	// ExitCodeEnv is environment variable containing exit code when running error and
+	// always hooks. Set to 0 if command succeeded
+	ExitCodeEnv = "TAU_EXIT_CODE"
+
+	// CommandEnv is environment variable with the tau command that is running
+	CommandEnv = "TAU_COMMAND"
+
+	// EventEnv is environment variable with the event hook is triggered on
+	EventEnv = "TAU_EVENT"
+
+	// SourceFileEnv is environment variable with full path of source file
+	SourceFileEnv = "TAU_SOURCE_FILE"
+
+	// SourceNameEnv is environment variable with name of source file, without extension
+	SourceNameEnv = "TAU_SOURCE_NAME"
+
+	// ModuleDirEnv is environment variable with directory module is downloaded to
+	ModuleDirEnv = "TAU_MODULE_DIR"
+
+	// PlanFileEnv is environment variable with path to the plan file
+	PlanFileEnv = "TAU_PLAN_FILE"
+
+	// VarFileEnv is environment variable with path to the input variables file
+	VarFileEnv = "TAU_VAR_FILE"
+
+	// OutputFileEnv is environment variable with path to json file with module outputs.
+	// Only set when outputs have been read, after apply and output commands
+	OutputFileEnv = "TAU_OUTPUT_FILE"
+
+	// OutputEnvPrefix is prefix for environment variables with module outputs. Characters in
+	// output names that are not valid in environment variables are replaced with _
+	OutputEnvPrefix = "TAU_OUTPUT_"
+)
+
+// contextEnv returns the environment variables describing current execution context
+// that are sent to hooks in addition to environment for file.
+func contextEnv(file *loader.ParsedFile, event, command string) (map[string]string, error) {
+	env := map[string]string{
+		CommandEnv: command,
+		EventEnv:   event,
+	}
+
+	if file.File != nil {
+		env[SourceFileEnv] = file.FullPath
+		env[SourceNameEnv] = file.DeploymentName()
+		env[ModuleDirEnv] = file.ModuleDir()
+		env[PlanFileEnv] = file.PlanFile()
+		env[VarFileEnv] = file.VariableFile()
+	}
+
+	if file.Outputs == nil {
+		return env, nil
+	}
+
+	env[OutputFileEnv] = file.OutputFile()
+
+	for name, value := range file.Outputs {
+		str, err := outputValueString(value)
+		if err != nil {
+			return nil, err
+		}
+
+		env[fmt.Sprintf("%s%s", OutputEnvPrefix, strings.ToUpper(output.VariableName(name)))] = str
+	}
+
+	return env, nil
+}
+
+// outputValueString converts value to a string that can be used as environment variable.
+// Primitive values are converted to strings, while lists and objects are json encoded.
+func outputValueString(value cty.Value) (string, error) {
+	if value.IsNull() || !value.IsKnown() {
+		return "", nil
+	}
+
+	switch value.Type() {
+	case cty.String:
+		return value.AsString(), nil
+	case cty.Number:
+		return value.AsBigFloat().Text('f', -1), nil
+	case cty.Bool:
+		if value.True() {
+			return "true", nil
+		}
+
+		return "false", nil
+	}
+
+	bytes, err := ctyjson.Marshal(value, value.Type())
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes), nil
+}
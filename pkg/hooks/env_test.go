@@ -0,0 +1,53 @@
+package hooks
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+
+	"github.com/avinor/tau/pkg/config/loader"
+)
+
+func TestOutputValueString(t *testing.T) {
+	tests := []struct {
+		value   cty.Value
+		expects string
+	}{
+		{cty.StringVal("value"), "value"},
+		{cty.NumberIntVal(5), "5"},
+		{cty.NumberFloatVal(1.5), "1.5"},
+		{cty.True, "true"},
+		{cty.NullVal(cty.String), ""},
+		{cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}), `["a","b"]`},
+		{cty.ObjectVal(map[string]cty.Value{"key": cty.StringVal("value")}), `{"key":"value"}`},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			str, err := outputValueString(test.value)
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expects, str)
+		})
+	}
+}
+
+func TestContextEnv(t *testing.T) {
+	file := &loader.ParsedFile{
+		Outputs: map[string]cty.Value{
+			"resource_id": cty.StringVal("id"),
+			"vnet-name":   cty.StringVal("vnet"),
+		},
+	}
+
+	env, err := contextEnv(file, "finish", "apply")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "apply", env[CommandEnv])
+	assert.Equal(t, "finish", env[EventEnv])
+	assert.Equal(t, "id", env["TAU_OUTPUT_RESOURCE_ID"])
+	assert.Equal(t, "vnet", env["TAU_OUTPUT_VNET_NAME"])
+	assert.Equal(t, file.OutputFile(), env[OutputFileEnv])
+}
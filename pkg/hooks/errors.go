@@ -0,0 +1,40 @@
+package hooks
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeoutError is returned when a hook does not complete within its timeout
+type TimeoutError struct {
+	Hook    string
+	Timeout time.Duration
+	Err     error
+}
+
+// Error returns the error message
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("hook %s timed out after %s", e.Hook, e.Timeout)
+}
+
+// Unwrap returns the underlying error
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
+// RetryError is returned when a hook has failed on every attempt
+type RetryError struct {
+	Hook     string
+	Attempts int
+	Err      error
+}
+
+// Error returns the error message including the error from last attempt
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("hook %s failed after %v attempts: %s", e.Hook, e.Attempts, e.Err)
+}
+
+// Unwrap returns the error from last attempt
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,84 @@
+package inline
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/avinor/tau/pkg/config"
+	pstrings "github.com/avinor/tau/pkg/helpers/strings"
+	"github.com/avinor/tau/pkg/hooks/command"
+	"github.com/avinor/tau/pkg/hooks/def"
+)
+
+var (
+	// DefaultInterpreter is used to execute inline scripts when interpreter is not defined
+	// and script does not start with a shebang
+	DefaultInterpreter = []string{"sh"}
+)
+
+// Creator that can write inline scripts to disk and execute them
+type Creator struct {
+	Options *def.Options
+}
+
+// CanCreate checks if hook has an inline script
+func (c *Creator) CanCreate(hook *config.Hook) bool {
+	if !hook.HasInline() {
+		return false
+	}
+
+	return true
+}
+
+// Create a new executor from hook that writes the script to cache directory and returns
+// a command executor running the script with interpreter. If interpreter is not defined, and
+// script starts with a shebang, script is executed directly.
+func (c *Creator) Create(hook *config.Hook) (def.Executor, error) {
+	var interpreter []string
+	var arguments []string
+	var workingDir string
+
+	script := *hook.Inline
+
+	if hook.Interpreter != nil {
+		interpreter = *hook.Interpreter
+	} else if !strings.HasPrefix(script, "#!") {
+		interpreter = DefaultInterpreter
+	}
+
+	if hook.WorkingDir != nil {
+		workingDir = *hook.WorkingDir
+	}
+
+	dst := filepath.Join(c.Options.CacheDir, hook.Type)
+	file := filepath.Join(dst, "inline-"+pstrings.Hash(script))
+
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(file, []byte(script), 0700); err != nil {
+		return nil, err
+	}
+
+	cmd := file
+	if len(interpreter) > 0 {
+		cmd = interpreter[0]
+		arguments = append(arguments, interpreter[1:]...)
+		arguments = append(arguments, file)
+	}
+
+	if hook.Arguments != nil {
+		arguments = append(arguments, *hook.Arguments...)
+	}
+
+	return &command.Executor{
+		Command:         cmd,
+		Arguments:       arguments,
+		WorkingDir:      workingDir,
+		Timeout:         hook.GetTimeout(),
+		SensitiveOutput: hook.SetEnv != nil && *hook.SetEnv,
+	}, nil
+}
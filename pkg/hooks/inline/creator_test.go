@@ -0,0 +1,70 @@
+package inline
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/avinor/tau/pkg/config"
+	"github.com/avinor/tau/pkg/helpers/strings"
+	"github.com/avinor/tau/pkg/hooks/command"
+	"github.com/avinor/tau/pkg/hooks/def"
+)
+
+func TestCreate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tau-inline")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	creator := &Creator{
+		Options: &def.Options{CacheDir: dir},
+	}
+
+	script := "echo hello"
+	shebang := "#!/usr/bin/env bash\necho hello"
+	file := func(script string) string {
+		return filepath.Join(dir, "name", "inline-"+strings.Hash(script))
+	}
+
+	tests := []struct {
+		Hook      *config.Hook
+		Command   string
+		Arguments []string
+	}{
+		{
+			&config.Hook{Type: "name", Inline: &script},
+			"sh",
+			[]string{file(script)},
+		},
+		{
+			&config.Hook{Type: "name", Inline: &script, Interpreter: &[]string{"bash", "-euo", "pipefail"}, Arguments: &[]string{"arg"}},
+			"bash",
+			[]string{"-euo", "pipefail", file(script), "arg"},
+		},
+		{
+			&config.Hook{Type: "name", Inline: &shebang},
+			file(shebang),
+			nil,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			exec, err := creator.Create(test.Hook)
+			assert.NoError(t, err)
+
+			cmd, ok := exec.(*command.Executor)
+			assert.True(t, ok)
+			assert.Equal(t, test.Command, cmd.Command)
+			assert.Equal(t, test.Arguments, cmd.Arguments)
+
+			content, err := ioutil.ReadFile(file(*test.Hook.Inline))
+			assert.NoError(t, err)
+			assert.Equal(t, *test.Hook.Inline, string(content))
+		})
+	}
+}
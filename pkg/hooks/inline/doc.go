@@ -0,0 +1,3 @@
+// Package inline contains an execution creator for scripts defined inline in config. The
+// script is written to cache directory and executed with the command.Executor.
+package inline
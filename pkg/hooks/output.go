@@ -0,0 +1,46 @@
+package hooks
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+
+	"github.com/avinor/tau/pkg/config"
+	pstrings "github.com/avinor/tau/pkg/helpers/strings"
+)
+
+// parseOutput parses the output from hook according to the output_format defined on hook.
+// If parsing fails it returns a diagnostic pointing to the hook definition in source file.
+func parseOutput(hook *config.Hook, output string) (map[string]string, error) {
+	var values map[string]string
+	var err error
+
+	format, path := hook.GetOutputFormat()
+
+	switch format {
+	case "json":
+		values, err = pstrings.ParseJSONVars(output, path)
+	case "dotenv":
+		values, err = pstrings.ParseDotenvVars(output)
+	case "export":
+		values, err = pstrings.ParseExportVars(output)
+	default:
+		values = pstrings.ParseVars(output)
+	}
+
+	if err == nil {
+		return values, nil
+	}
+
+	diag := &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid hook output",
+		Detail:   fmt.Sprintf("Could not parse output from hook %q as %s: %s", hook.Type, format, err),
+	}
+
+	if hook.DefRange.Filename != "" {
+		diag.Subject = hook.DefRange.Ptr()
+	}
+
+	return nil, hcl.Diagnostics{diag}
+}
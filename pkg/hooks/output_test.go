@@ -0,0 +1,60 @@
+package hooks
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/avinor/tau/pkg/config"
+	"github.com/avinor/tau/pkg/helpers/strings"
+)
+
+func TestParseOutput(t *testing.T) {
+	tests := []struct {
+		hook    *config.Hook
+		output  string
+		expects map[string]string
+		err     bool
+	}{
+		{&config.Hook{}, "key = value", map[string]string{"key": "value"}, false},
+		{&config.Hook{OutputFormat: strings.ToPointer("keyvalue")}, "key = value", map[string]string{"key": "value"}, false},
+		{&config.Hook{OutputFormat: strings.ToPointer("json")}, `{"key": "a=b"}`, map[string]string{"key": "a=b"}, false},
+		{&config.Hook{OutputFormat: strings.ToPointer("json:.data")}, `{"data": {"key": "value"}}`, map[string]string{"key": "value"}, false},
+		{&config.Hook{OutputFormat: strings.ToPointer("dotenv")}, `KEY="quoted"`, map[string]string{"KEY": "quoted"}, false},
+		{&config.Hook{OutputFormat: strings.ToPointer("export")}, `export KEY='value'`, map[string]string{"KEY": "value"}, false},
+		{&config.Hook{OutputFormat: strings.ToPointer("json")}, `invalid`, nil, true},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			values, err := parseOutput(test.hook, test.output)
+
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expects, values)
+		})
+	}
+}
+
+func TestParseOutputErrorLocation(t *testing.T) {
+	hook := &config.Hook{
+		Type:         "token",
+		OutputFormat: strings.ToPointer("json"),
+		DefRange: hcl.Range{
+			Filename: "/tmp/common_auto.hcl",
+			Start:    hcl.Pos{Line: 3, Column: 1},
+			End:      hcl.Pos{Line: 3, Column: 13},
+		},
+	}
+
+	_, err := parseOutput(hook, "not json")
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "/tmp/common_auto.hcl:3,1-13")
+}
@@ -1,8 +1,14 @@
 package hooks
 
 import (
+	"context"
+	goerrors "errors"
+	"fmt"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-errors/errors"
 
@@ -12,12 +18,22 @@ import (
 	"github.com/avinor/tau/pkg/helpers/ui"
 	"github.com/avinor/tau/pkg/hooks/command"
 	"github.com/avinor/tau/pkg/hooks/def"
+	"github.com/avinor/tau/pkg/hooks/inline"
 	"github.com/avinor/tau/pkg/hooks/script"
+	"github.com/avinor/tau/pkg/shell"
 )
 
 var (
 	// noExecutorFound is returned when no executor is found among available ones
 	noExecutorFound = errors.Errorf("no available executor is found for hook")
+
+	// deploymentEvents are events that run after command for a deployment, when context
+	// contains the outcome and outputs of that deployment
+	deploymentEvents = map[string]bool{
+		"finish": true,
+		"error":  true,
+		"always": true,
+	}
 )
 
 // Runner that can execute hooks
@@ -30,28 +46,57 @@ type Runner struct {
 	// cache of all created executors
 	cache map[string]def.Executor
 
+	// outputCache stores output from hooks with cache_ttl between executions
+	outputCache *OutputCache
+
 	creators []def.ExecutorCreator
 }
 
 // New creates a new runner for executing hooks.
 func New(options *def.Options) *Runner {
 	return &Runner{
-		options: options,
-		cache:   map[string]def.Executor{},
+		options:     options,
+		cache:       map[string]def.Executor{},
+		outputCache: NewOutputCache(filepath.Join(options.CacheDir, CacheDirName)),
 		creators: []def.ExecutorCreator{
 			&command.Creator{},
 			&script.Creator{
 				Options: options,
 			},
+			&inline.Creator{
+				Options: options,
+			},
 		},
 	}
 }
 
 // Run all hooks in source for a specific event. Command input can filter hooks that should only be run
 // got specific terraform commands.
+//
+// Hooks run in the order defined in config, which makes sure hooks run after the hooks they
+// depend on. Environment variables set by a hook are available for all hooks running after it.
+// If a hook fails, but fail_on_error is false, all hooks depending on it will be skipped.
+//
+// In addition to environment variables for file, hooks also get environment variables
+// describing the execution context, see contextEnv. Hooks for deploymentEvents are not
+// shared between deployments, so they run for every deployment.
 func (r *Runner) Run(file *loader.ParsedFile, event, command string) error {
+	defer ui.WithContext(ui.PhaseContext, "hooks")()
+
+	failed := map[string]bool{}
+
+	hookEnv, err := contextEnv(file, event, command)
+	if err != nil {
+		return err
+	}
+
+	contextKey := ""
+	if deploymentEvents[strings.ToLower(event)] {
+		contextKey = getContextKey(hookEnv)
+	}
+
 	for _, hook := range file.Config.Hooks {
-		exec, err := r.getExecutor(hook)
+		exec, err := r.getExecutor(hook, contextKey)
 		if err != nil {
 			return err
 		}
@@ -61,11 +106,28 @@ func (r *Runner) Run(file *loader.ParsedFile, event, command string) error {
 			continue
 		}
 
+		if dep := failedDependency(hook, failed); dep != "" {
+			ui.Warn("- Skipping hook %s, depends on failed hook %s", hook.Type, dep)
+			failed[hook.Type] = true
+			continue
+		}
+
 		if !exec.HasRun() || (hook.DisableCache != nil && *hook.DisableCache) {
 			ui.Info("- Running hook %s...", hook.Type)
 
-			if err := exec.Run(file.Env); err != nil {
+			env := map[string]string{}
+			for key, value := range hookEnv {
+				env[key] = value
+			}
+
+			for key, value := range file.Env {
+				env[key] = value
+			}
+
+			if err := runWithRetries(file.Context(), hook, exec, env); err != nil {
 				if hook.FailOnError != nil && !*hook.FailOnError {
+					ui.Warn("- Hook %s failed: %s", hook.Type, err)
+					failed[hook.Type] = true
 					continue
 				}
 
@@ -74,7 +136,18 @@ func (r *Runner) Run(file *loader.ParsedFile, event, command string) error {
 		}
 
 		if hook.SetEnv != nil && *hook.SetEnv {
-			for key, value := range pstrings.ParseVars(exec.Output()) {
+			vars, err := parseOutput(hook, exec.Output())
+			if err != nil {
+				if hook.FailOnError != nil && !*hook.FailOnError {
+					ui.Warn("- Hook %s failed: %s", hook.Type, err)
+					failed[hook.Type] = true
+					continue
+				}
+
+				return err
+			}
+
+			for key, value := range vars {
 				ui.Debug("setting env %s", key)
 				file.Env[key] = value
 			}
@@ -84,6 +157,17 @@ func (r *Runner) Run(file *loader.ParsedFile, event, command string) error {
 	return nil
 }
 
+// HasHooks returns true if file has any hooks that should run for event and command.
+func (r *Runner) HasHooks(file *loader.ParsedFile, event, command string) bool {
+	for _, hook := range file.Config.Hooks {
+		if r.ShouldRun(hook, event, command) {
+			return true
+		}
+	}
+
+	return false
+}
+
 // ShouldRun checks if the hook should run for event and command sent as input.
 // Returns true if it should continue to process hook, and false otherwise.
 func (r *Runner) ShouldRun(hook *config.Hook, event, command string) bool {
@@ -117,10 +201,69 @@ func (r *Runner) ShouldRun(hook *config.Hook, event, command string) bool {
 	return false
 }
 
+// runWithRetries runs the executor and retries it if it fails, as many times as defined
+// in hook retries. Time to wait is doubled for every retry. If all attempts fail it returns
+// a RetryError, a timeout returns a TimeoutError, or the error from executor if no retries.
+func runWithRetries(ctx context.Context, hook *config.Hook, exec def.Executor, env map[string]string) error {
+	retries := hook.GetRetries()
+	backoff := hook.GetRetryBackoff()
+
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			ui.Warn("- Hook %s failed: %s, retrying in %s (%v/%v)", hook.Type, err, backoff, attempt, retries)
+
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return err
+			}
+
+			backoff *= 2
+		}
+
+		if err = exec.Run(ctx, env); err == nil {
+			return nil
+		}
+
+		var timeout *shell.TimeoutError
+		if goerrors.As(err, &timeout) {
+			err = &TimeoutError{Hook: hook.Type, Timeout: timeout.Timeout, Err: err}
+		}
+	}
+
+	if retries == 0 {
+		return err
+	}
+
+	return &RetryError{
+		Hook:     hook.Type,
+		Attempts: retries + 1,
+		Err:      err,
+	}
+}
+
+// failedDependency returns name of the first hook that hook depends on that has failed.
+// Returns empty string if none of the dependencies have failed.
+func failedDependency(hook *config.Hook, failed map[string]bool) string {
+	if hook.DependsOn == nil {
+		return ""
+	}
+
+	for _, dep := range *hook.DependsOn {
+		if failed[dep] {
+			return dep
+		}
+	}
+
+	return ""
+}
+
 // getExecutor checks if executor has already been created and returns from cache if it has.
 // If not it will create a new executor using the creators and store in cache for later use.
-func (r *Runner) getExecutor(hook *config.Hook) (def.Executor, error) {
-	key := getCacheKey(hook)
+// Executors are only shared between runs with same contextKey.
+func (r *Runner) getExecutor(hook *config.Hook, contextKey string) (def.Executor, error) {
+	key := getCacheKey(hook) + contextKey
 	r.cacheLock.Lock()
 	defer r.cacheLock.Unlock()
 
@@ -135,6 +278,14 @@ func (r *Runner) getExecutor(hook *config.Hook) (def.Executor, error) {
 				return nil, err
 			}
 
+			if r.outputCache != nil && hook.GetCacheTTL() > 0 {
+				executor = &cachedExecutor{
+					Executor: executor,
+					cache:    r.outputCache,
+					hook:     hook,
+				}
+			}
+
 			r.cache[key] = executor
 			return r.cache[key], nil
 		}
@@ -143,8 +294,9 @@ func (r *Runner) getExecutor(hook *config.Hook) (def.Executor, error) {
 	return nil, noExecutorFound
 }
 
-// getCacheKey returns a unique cache key for a given command with arguments. If disable_cache
-// is set it will generate a random key to make sure it creates new instances
+// getCacheKey returns a unique cache key for a given command with arguments and the settings
+// that change how it runs, so hooks with different timeout, retries or cache_ttl do not share
+// executor. If disable_cache is set it will generate a random key to make sure it creates new instances
 func getCacheKey(hook *config.Hook) string {
 	var sb strings.Builder
 
@@ -160,9 +312,51 @@ func getCacheKey(hook *config.Hook) string {
 		sb.WriteString(*hook.Script)
 	}
 
+	if hook.Inline != nil {
+		sb.WriteString(*hook.Inline)
+	}
+
+	if hook.Interpreter != nil {
+		sb.WriteString(strings.Join(*hook.Interpreter, "_"))
+	}
+
 	if hook.Arguments != nil {
 		sb.WriteString(strings.Join(*hook.Arguments, "_"))
 	}
 
+	if hook.Timeout != nil {
+		sb.WriteString(fmt.Sprintf("_timeout=%s", *hook.Timeout))
+	}
+
+	if hook.Retries != nil {
+		sb.WriteString(fmt.Sprintf("_retries=%v", *hook.Retries))
+	}
+
+	if hook.RetryBackoff != nil {
+		sb.WriteString(fmt.Sprintf("_retry_backoff=%s", *hook.RetryBackoff))
+	}
+
+	if hook.CacheTTL != nil {
+		sb.WriteString(fmt.Sprintf("_cache_ttl=%s", *hook.CacheTTL))
+	}
+
 	return sb.String()
 }
+
+// getContextKey returns a key identifying the execution context in env, so executors for
+// different deployments, or different outputs, are not shared
+func getContextKey(env map[string]string) string {
+	keys := []string{}
+	for key := range env {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, key := range keys {
+		sb.WriteString(fmt.Sprintf("%s=%s\n", key, env[key]))
+	}
+
+	return "_context=" + pstrings.Hash(sb.String())
+}
@@ -1,13 +1,23 @@
 package hooks
 
 import (
+	"context"
+	goerrors "errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/avinor/tau/pkg/config"
+	"github.com/avinor/tau/pkg/config/loader"
 	"github.com/avinor/tau/pkg/helpers/strings"
+	"github.com/avinor/tau/pkg/hooks/command"
+	"github.com/avinor/tau/pkg/hooks/def"
+	"github.com/avinor/tau/pkg/shell"
 )
 
 func TestShouldRun(t *testing.T) {
@@ -30,6 +40,11 @@ func TestShouldRun(t *testing.T) {
 		{&config.Hook{TriggerOn: strings.ToPointer("finish:init")}, "finish", "INIT", true},
 		{&config.Hook{TriggerOn: strings.ToPointer("finish:init,plan")}, "finish", "plan", true},
 		{&config.Hook{TriggerOn: strings.ToPointer("finish:init,plan")}, "finish", "init", true},
+		{&config.Hook{TriggerOn: strings.ToPointer("error")}, "error", "apply", true},
+		{&config.Hook{TriggerOn: strings.ToPointer("error:apply")}, "always", "apply", false},
+		{&config.Hook{TriggerOn: strings.ToPointer("always")}, "always", "plan", true},
+		{&config.Hook{TriggerOn: strings.ToPointer("pre_resolve:plan")}, "pre_resolve", "plan", true},
+		{&config.Hook{TriggerOn: strings.ToPointer("post_resolve")}, "pre_resolve", "plan", false},
 	}
 
 	runner := Runner{}
@@ -42,3 +57,285 @@ func TestShouldRun(t *testing.T) {
 		})
 	}
 }
+
+// fakeCreator creates fakeExecutors for all hooks. Returns executor for command in executors,
+// or a new executor, recorded in created, if there is none.
+type fakeCreator struct {
+	executors map[string]*fakeExecutor
+	created   []*fakeExecutor
+}
+
+func (c *fakeCreator) CanCreate(hook *config.Hook) bool {
+	return true
+}
+
+func (c *fakeCreator) Create(hook *config.Hook) (def.Executor, error) {
+	if exec, ok := c.executors[*hook.Command]; ok {
+		return exec, nil
+	}
+
+	exec := &fakeExecutor{}
+	c.created = append(c.created, exec)
+
+	return exec, nil
+}
+
+// fakeExecutor records the environment it was run with and returns output.
+// The first number of runs defined by failures returns an error.
+type fakeExecutor struct {
+	output   string
+	err      error
+	env      map[string]string
+	hasRun   bool
+	runs     int
+	failures int
+}
+
+func (e *fakeExecutor) HasRun() bool {
+	return e.hasRun
+}
+
+func (e *fakeExecutor) Run(ctx context.Context, env map[string]string) error {
+	e.hasRun = true
+	e.runs++
+	e.env = map[string]string{}
+	for k, v := range env {
+		e.env[k] = v
+	}
+
+	if e.runs <= e.failures {
+		return fmt.Errorf("failed attempt %v", e.runs)
+	}
+
+	return e.err
+}
+
+func (e *fakeExecutor) Output() string {
+	return e.output
+}
+
+func TestRunSetsEnvForSubsequentHooks(t *testing.T) {
+	token := &fakeExecutor{output: "TOKEN=secret"}
+	key := &fakeExecutor{output: "ACCESS_KEY=key"}
+	failing := &fakeExecutor{err: fmt.Errorf("failed")}
+	skipped := &fakeExecutor{}
+
+	runner := &Runner{
+		cache: map[string]def.Executor{},
+		creators: []def.ExecutorCreator{
+			&fakeCreator{executors: map[string]*fakeExecutor{
+				"token":   token,
+				"key":     key,
+				"failing": failing,
+				"skipped": skipped,
+			}},
+		},
+	}
+
+	setEnv := true
+	failOnError := false
+
+	file := &loader.ParsedFile{
+		Config: &config.Config{
+			Hooks: []*config.Hook{
+				{Type: "token", Command: strings.ToPointer("token"), TriggerOn: strings.ToPointer("prepare"), SetEnv: &setEnv},
+				{Type: "key", Command: strings.ToPointer("key"), TriggerOn: strings.ToPointer("prepare"), SetEnv: &setEnv, DependsOn: &[]string{"token"}},
+				{Type: "failing", Command: strings.ToPointer("failing"), TriggerOn: strings.ToPointer("prepare"), FailOnError: &failOnError},
+				{Type: "skipped", Command: strings.ToPointer("skipped"), TriggerOn: strings.ToPointer("prepare"), DependsOn: &[]string{"failing"}},
+			},
+		},
+		Env: map[string]string{},
+	}
+
+	err := runner.Run(file, "prepare", "init")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", key.env["TOKEN"])
+	assert.Equal(t, "key", file.Env["ACCESS_KEY"])
+	assert.False(t, skipped.hasRun)
+}
+
+func TestRunSetEnvNotLogged(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tau-hook-log")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	log, err := shell.OpenLog(filepath.Join(dir, "prepare.log"))
+	assert.NoError(t, err)
+	defer shell.SetLog(log)()
+
+	runner := &Runner{
+		cache:    map[string]def.Executor{},
+		creators: []def.ExecutorCreator{&command.Creator{}},
+	}
+
+	setEnv := true
+
+	file := &loader.ParsedFile{
+		Config: &config.Config{
+			Hooks: []*config.Hook{
+				{Type: "login", Command: strings.ToPointer("echo"), Arguments: &[]string{"ARM_CLIENT_SECRET=verysecret"}, TriggerOn: strings.ToPointer("prepare"), SetEnv: &setEnv},
+				{Type: "print", Command: strings.ToPointer("echo"), Arguments: &[]string{"not a secret"}, TriggerOn: strings.ToPointer("prepare")},
+			},
+		},
+		Env: map[string]string{},
+	}
+
+	assert.NoError(t, runner.Run(file, "prepare", "init"))
+	assert.NoError(t, log.Close())
+	assert.Equal(t, "verysecret", file.Env["ARM_CLIENT_SECRET"])
+
+	content, err := ioutil.ReadFile(log.Path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(content), "verysecret")
+	assert.Contains(t, string(content), "not a secret")
+}
+
+func TestRunSharedHookForDeployments(t *testing.T) {
+	tests := []struct {
+		event string
+		runs  int
+	}{
+		{"prepare", 1},
+		{"finish", 2},
+		{"error", 2},
+		{"always", 2},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			creator := &fakeCreator{}
+			runner := &Runner{
+				cache:    map[string]def.Executor{},
+				creators: []def.ExecutorCreator{creator},
+			}
+
+			hooks := []*config.Hook{
+				{Type: "notify", Command: strings.ToPointer("notify"), TriggerOn: strings.ToPointer(test.event)},
+			}
+
+			for _, name := range []string{"vnet", "aks"} {
+				f, err := config.NewFile(fmt.Sprintf("/shared-hook-%02d/%s.hcl", i, name), []byte(""))
+				assert.NoError(t, err)
+
+				file := &loader.ParsedFile{
+					File:   f,
+					Config: &config.Config{Hooks: hooks},
+					Env:    map[string]string{},
+				}
+
+				assert.NoError(t, runner.Run(file, test.event, "apply"))
+			}
+
+			runs := 0
+			names := []string{}
+			for _, exec := range creator.created {
+				runs += exec.runs
+				names = append(names, exec.env[SourceNameEnv])
+			}
+
+			assert.Equal(t, test.runs, runs)
+
+			if test.runs > 1 {
+				assert.Equal(t, []string{"vnet", "aks"}, names)
+			}
+		})
+	}
+}
+
+func TestRunWithRetries(t *testing.T) {
+	tests := []struct {
+		retries  int
+		failures int
+		runs     int
+		err      bool
+	}{
+		{0, 0, 1, false},
+		{0, 1, 1, true},
+		{2, 1, 2, false},
+		{2, 2, 3, false},
+		{2, 3, 3, true},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			exec := &fakeExecutor{failures: test.failures}
+			hook := &config.Hook{
+				Type:         "retry",
+				Retries:      &test.retries,
+				RetryBackoff: strings.ToPointer("1ms"),
+			}
+
+			err := runWithRetries(context.Background(), hook, exec, map[string]string{})
+
+			assert.Equal(t, test.runs, exec.runs)
+
+			if !test.err {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.Error(t, err)
+
+			if test.retries > 0 {
+				assert.IsType(t, &RetryError{}, err)
+			}
+		})
+	}
+}
+
+func TestRunWithRetriesTimeout(t *testing.T) {
+	timeout := &shell.TimeoutError{Command: "sleep", Timeout: time.Second}
+
+	tests := []struct {
+		retries int
+		err     error
+	}{
+		{0, timeout},
+		{0, fmt.Errorf("executing hook: %w", timeout)},
+		{1, fmt.Errorf("executing hook: %w", timeout)},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			exec := &fakeExecutor{err: test.err}
+			hook := &config.Hook{
+				Type:         "slow",
+				Retries:      &test.retries,
+				RetryBackoff: strings.ToPointer("1ms"),
+			}
+
+			err := runWithRetries(context.Background(), hook, exec, map[string]string{})
+
+			var timeoutErr *TimeoutError
+			assert.True(t, goerrors.As(err, &timeoutErr))
+			assert.Equal(t, "slow", timeoutErr.Hook)
+			assert.Equal(t, time.Second, timeoutErr.Timeout)
+			assert.Equal(t, test.retries+1, exec.runs)
+		})
+	}
+}
+
+func TestGetCacheKey(t *testing.T) {
+	one := 1
+
+	tests := []struct {
+		hook  *config.Hook
+		equal bool
+	}{
+		{&config.Hook{Command: strings.ToPointer("cmd")}, true},
+		{&config.Hook{Command: strings.ToPointer("cmd"), Timeout: strings.ToPointer("5m")}, false},
+		{&config.Hook{Command: strings.ToPointer("cmd"), Retries: &one}, false},
+		{&config.Hook{Command: strings.ToPointer("cmd"), RetryBackoff: strings.ToPointer("10s")}, false},
+		{&config.Hook{Command: strings.ToPointer("cmd"), CacheTTL: strings.ToPointer("30m")}, false},
+		{&config.Hook{Command: strings.ToPointer("other")}, false},
+	}
+
+	base := getCacheKey(&config.Hook{Command: strings.ToPointer("cmd")})
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			assert.Equal(t, test.equal, getCacheKey(test.hook) == base)
+		})
+	}
+}
@@ -23,7 +23,8 @@ func (c *Creator) CanCreate(hook *config.Hook) bool {
 	return true
 }
 
-// Create a new executor from hook that first downloads script then returns a command executor
+// Create a new executor from hook that first downloads script then returns a command executor.
+// If hook defines checksum or signature the script is verified before it is made executable.
 func (c *Creator) Create(hook *config.Hook) (def.Executor, error) {
 	var script string
 	var arguments []string
@@ -46,13 +47,30 @@ func (c *Creator) Create(hook *config.Hook) (def.Executor, error) {
 		return nil, err
 	}
 
+	// Verify script before making it executable
+	if err := verify(cmd, hook); err != nil {
+		os.Remove(cmd)
+		return nil, err
+	}
+
 	if err := os.Chmod(cmd, 0755); err != nil {
 		return nil, err
 	}
 
-	return &command.Executor{
-		Command:    cmd,
-		Arguments:  arguments,
-		WorkingDir: workingDir,
+	executor := &command.Executor{
+		Command:         cmd,
+		Arguments:       arguments,
+		WorkingDir:      workingDir,
+		Timeout:         hook.GetTimeout(),
+		SensitiveOutput: hook.SetEnv != nil && *hook.SetEnv,
+	}
+
+	if !hook.HasIntegrityCheck() {
+		return executor, nil
+	}
+
+	return &Executor{
+		Executor: executor,
+		hook:     hook,
 	}, nil
 }
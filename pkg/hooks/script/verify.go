@@ -0,0 +1,108 @@
+package script
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"io/ioutil"
+	"strings"
+
+	"github.com/go-errors/errors"
+
+	"github.com/avinor/tau/pkg/config"
+	"github.com/avinor/tau/pkg/hooks/command"
+)
+
+var (
+	// invalidPublicKey is returned if public key is not a valid ed25519 public key
+	invalidPublicKey = errors.Errorf("script_public_key is not a valid ed25519 public key")
+
+	// invalidSignature is returned if signature is not base64 encoded
+	invalidSignature = errors.Errorf("script_signature is not a valid base64 encoded signature")
+)
+
+// Executor verifies the integrity of script before running it with the command executor.
+// Script is verified before every execution, so a script modified in cache will not run.
+type Executor struct {
+	*command.Executor
+
+	hook *config.Hook
+}
+
+// Run verifies the script and runs it if verification succeeds
+func (e *Executor) Run(ctx context.Context, env map[string]string) error {
+	if err := verify(e.Command, e.hook); err != nil {
+		return err
+	}
+
+	return e.Executor.Run(ctx, env)
+}
+
+// verify checks the sha256 checksum and signature of file if defined on hook
+func verify(file string, hook *config.Hook) error {
+	if !hook.HasIntegrityCheck() {
+		return nil
+	}
+
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	if hook.ScriptSHA256 != nil {
+		sum := sha256.Sum256(content)
+		actual := hex.EncodeToString(sum[:])
+
+		if !strings.EqualFold(actual, *hook.ScriptSHA256) {
+			return errors.Errorf("checksum of script %s does not match, expected %s but was %s", *hook.Script, *hook.ScriptSHA256, actual)
+		}
+	}
+
+	if hook.ScriptSignature != nil && hook.ScriptPublicKey != nil {
+		key, err := parsePublicKey(*hook.ScriptPublicKey)
+		if err != nil {
+			return err
+		}
+
+		signature, err := base64.StdEncoding.DecodeString(strings.TrimSpace(*hook.ScriptSignature))
+		if err != nil {
+			return invalidSignature
+		}
+
+		if !ed25519.Verify(key, content, signature) {
+			return errors.Errorf("signature of script %s is not valid", *hook.Script)
+		}
+	}
+
+	return nil
+}
+
+// parsePublicKey parses an ed25519 public key, either PEM encoded or base64 encoded raw key
+func parsePublicKey(key string) (ed25519.PublicKey, error) {
+	key = strings.TrimSpace(key)
+
+	if block, _ := pem.Decode([]byte(key)); block != nil {
+		parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, invalidPublicKey
+		}
+
+		edKey, ok := parsed.(ed25519.PublicKey)
+		if !ok {
+			return nil, invalidPublicKey
+		}
+
+		return edKey, nil
+	}
+
+	raw, err := base64.StdEncoding.DecodeString(key)
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return nil, invalidPublicKey
+	}
+
+	return ed25519.PublicKey(raw), nil
+}
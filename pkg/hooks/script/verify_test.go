@@ -0,0 +1,102 @@
+package script
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/avinor/tau/pkg/config"
+)
+
+func TestVerify(t *testing.T) {
+	content := []byte("#!/bin/sh\necho hello\n")
+
+	dir, err := ioutil.TempDir("", "tau-verify")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "script.sh")
+	assert.NoError(t, ioutil.WriteFile(file, content, 0644))
+
+	sum := sha256.Sum256(content)
+	checksum := hex.EncodeToString(sum[:])
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	signature := base64.StdEncoding.EncodeToString(ed25519.Sign(private, content))
+	rawKey := base64.StdEncoding.EncodeToString(public)
+
+	der, err := x509.MarshalPKIXPublicKey(public)
+	assert.NoError(t, err)
+	pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	otherPublic, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	otherKey := base64.StdEncoding.EncodeToString(otherPublic)
+
+	script := "https://example.com/script.sh"
+	invalid := "not base64!"
+	wrongChecksum := "0000000000000000000000000000000000000000000000000000000000000000"
+
+	tests := []struct {
+		Hook  *config.Hook
+		Error bool
+	}{
+		{
+			&config.Hook{Script: &script},
+			false,
+		},
+		{
+			&config.Hook{Script: &script, ScriptSHA256: &checksum},
+			false,
+		},
+		{
+			&config.Hook{Script: &script, ScriptSHA256: &wrongChecksum},
+			true,
+		},
+		{
+			&config.Hook{Script: &script, ScriptSignature: &signature, ScriptPublicKey: &rawKey},
+			false,
+		},
+		{
+			&config.Hook{Script: &script, ScriptSignature: &signature, ScriptPublicKey: &pemKey},
+			false,
+		},
+		{
+			&config.Hook{Script: &script, ScriptSHA256: &checksum, ScriptSignature: &signature, ScriptPublicKey: &otherKey},
+			true,
+		},
+		{
+			&config.Hook{Script: &script, ScriptSignature: &invalid, ScriptPublicKey: &rawKey},
+			true,
+		},
+		{
+			&config.Hook{Script: &script, ScriptSignature: &signature, ScriptPublicKey: &invalid},
+			true,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			err := verify(file, test.Hook)
+
+			if test.Error {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,3 @@
+// Package output formats outputs from deployments, for instance as json, yaml, environment
+// variables or terraform variables, so they can be used by other tools and scripts.
+package output
@@ -0,0 +1,231 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+// writeJSON writes value as json
+func writeJSON(w io.Writer, value cty.Value) error {
+	decoded, err := decodeJSON(value)
+	if err != nil {
+		return err
+	}
+
+	return encodeJSON(w, decoded)
+}
+
+// encodeJSON writes value as json, without escaping html characters like < and >
+func encodeJSON(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	return encoder.Encode(value)
+}
+
+// decodeJSON returns value decoded from json, so it can be encoded together with
+// other values. Numbers are kept as json numbers to not lose precision.
+func decodeJSON(value cty.Value) (interface{}, error) {
+	data, err := ctyjson.Marshal(value, value.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeRawJSON(data)
+}
+
+// decodeRawJSON decodes data, keeping numbers as json numbers
+func decodeRawJSON(data []byte) (interface{}, error) {
+	var decoded interface{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	return decoded, nil
+}
+
+// writeYAML writes value as yaml
+func writeYAML(w io.Writer, value cty.Value) error {
+	data, err := ctyjson.Marshal(value, value.Type())
+	if err != nil {
+		return err
+	}
+
+	yamlBytes, err := yaml.JSONToYAML(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(yamlBytes)
+	return err
+}
+
+// writeEnv writes values as environment variables, where objects, maps and lists are
+// flattened so every primitive value gets its own variable, TAU_<NAME>_<KEY>_<INDEX>.
+func writeEnv(w io.Writer, values map[string]cty.Value) error {
+	flattened := map[string]string{}
+
+	for name, value := range values {
+		if err := flatten(flattened, VariableName(envPrefix, name), value); err != nil {
+			return err
+		}
+	}
+
+	for _, key := range sortedStringKeys(flattened) {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", key, quoteDouble(flattened[key])); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeDotenv writes a variable, TAU_<NAME>, for each value in dotenv format. Objects,
+// maps and lists are json encoded.
+func writeDotenv(w io.Writer, values map[string]cty.Value) error {
+	return writeVariables(w, values, func(name, value string) string {
+		return fmt.Sprintf("%s=%s", strings.ToUpper(VariableName(envPrefix, name)), quoteDouble(value))
+	})
+}
+
+// writeExport writes a shell export statement, TAU_<NAME>, for each value. Objects, maps
+// and lists are json encoded.
+func writeExport(w io.Writer, values map[string]cty.Value) error {
+	return writeVariables(w, values, func(name, value string) string {
+		return fmt.Sprintf("export %s=%s", strings.ToUpper(VariableName(envPrefix, name)), quoteSingle(value))
+	})
+}
+
+// writeGithub writes values in format of GitHub Actions output file, where values
+// spanning multiple lines use a heredoc delimiter. If GithubOutput is set values are
+// appended to that file, otherwise written to w.
+func writeGithub(w io.Writer, values map[string]cty.Value, options *Options) error {
+	if options.GithubOutput != "" {
+		file, err := os.OpenFile(options.GithubOutput, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		w = file
+	}
+
+	return writeVariables(w, values, func(name, value string) string {
+		name = VariableName(name)
+
+		if !strings.ContainsAny(value, "\r\n") {
+			return fmt.Sprintf("%s=%s", name, value)
+		}
+
+		delimiter := "EOF"
+		for i := 1; strings.Contains(value, delimiter); i++ {
+			delimiter = fmt.Sprintf("EOF_%v", i)
+		}
+
+		return fmt.Sprintf("%s<<%s\n%s\n%s", name, delimiter, value, delimiter)
+	})
+}
+
+// writeTfvars writes values as terraform variables, so they can be used as a var file
+func writeTfvars(w io.Writer, values map[string]cty.Value) error {
+	f := hclwrite.NewEmptyFile()
+	body := f.Body()
+
+	for _, name := range sortedKeys(values) {
+		body.SetAttributeValue(VariableName(name), values[name])
+	}
+
+	_, err := w.Write(f.Bytes())
+	return err
+}
+
+// writeVariables writes a line for each value, in order of name, created by line function
+func writeVariables(w io.Writer, values map[string]cty.Value, line func(name, value string) string) error {
+	for _, name := range sortedKeys(values) {
+		str, err := valueString(values[name])
+		if err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintln(w, line(name, str)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// flatten adds all primitive values in value to values, with name of parent and key, or
+// index, joined by _ as name. Empty objects, maps and lists are json encoded.
+func flatten(values map[string]string, name string, value cty.Value) error {
+	name = strings.ToUpper(name)
+
+	if value.IsNull() || !value.IsKnown() || !value.CanIterateElements() || value.LengthInt() == 0 {
+		str, err := valueString(value)
+		if err != nil {
+			return err
+		}
+
+		values[name] = str
+		return nil
+	}
+
+	ty := value.Type()
+	isList := ty.IsListType() || ty.IsTupleType() || ty.IsSetType()
+
+	i := 0
+	for it := value.ElementIterator(); it.Next(); i++ {
+		key, elem := it.Element()
+
+		child := strconv.Itoa(i)
+		if !isList {
+			child = key.AsString()
+		}
+
+		if err := flatten(values, VariableName(name, child), elem); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// quoteDouble quotes value in double quotes, escaping characters that are not allowed
+func quoteDouble(value string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+
+	return fmt.Sprintf(`"%s"`, replacer.Replace(value))
+}
+
+// quoteSingle quotes value in single quotes for shell, where a single quote in value ends
+// the quoted string, is escaped and then starts a new quoted string
+func quoteSingle(value string) string {
+	return fmt.Sprintf("'%s'", strings.Replace(value, "'", `'\''`, -1))
+}
+
+// sortedStringKeys returns keys in values sorted
+func sortedStringKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,265 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+	ctyjson "github.com/zclconf/go-cty/cty/json"
+)
+
+const (
+	// SensitiveValue replaces values of sensitive outputs, unless sensitive values are shown
+	SensitiveValue = "<sensitive>"
+
+	// envPrefix is prefix for names of environment variables
+	envPrefix = "TAU"
+)
+
+var (
+	// Formats are the formats outputs can be written in
+	Formats = []string{"json", "yaml", "env", "dotenv", "export", "tfvars", "github", "terraform-json"}
+
+	// invalidNameRegexp matches characters that are not valid in names of variables
+	invalidNameRegexp = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+	// formatNotSupported is returned if format is not one of Formats
+	formatNotSupported = errors.Errorf("output format has to be one of: %s", strings.Join(Formats, ", "))
+)
+
+// Output is the value of a single output from a deployment
+type Output struct {
+	Value     cty.Value
+	Sensitive bool
+}
+
+// Outputs are all outputs from a deployment, by name
+type Outputs map[string]*Output
+
+// Options for writing outputs
+type Options struct {
+	// ShowSensitive writes values of sensitive outputs, instead of replacing them
+	// with SensitiveValue
+	ShowSensitive bool
+
+	// GithubOutput is the file outputs are appended to in github format. If empty
+	// they are written to the writer.
+	GithubOutput string
+}
+
+// NewOutputs creates outputs from values, where outputs in sensitive are marked as sensitive
+func NewOutputs(values map[string]cty.Value, sensitive map[string]bool) Outputs {
+	outputs := Outputs{}
+
+	for name, value := range values {
+		outputs[name] = &Output{
+			Value:     value,
+			Sensitive: sensitive[name],
+		}
+	}
+
+	return outputs
+}
+
+// IsValidFormat returns true if format is one of Formats
+func IsValidFormat(format string) bool {
+	for _, f := range Formats {
+		if f == format {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Write writes outputs from a single deployment to w in format
+func Write(w io.Writer, format string, outputs Outputs, options *Options) error {
+	if options == nil {
+		options = &Options{}
+	}
+
+	if format == "terraform-json" {
+		value, err := terraformJSON(outputs, options)
+		if err != nil {
+			return err
+		}
+
+		return encodeJSON(w, value)
+	}
+
+	return write(w, format, outputs.values(options), options)
+}
+
+// WriteDeployments writes outputs from several deployments to w in format. Outputs are
+// keyed by deployment name, so for json formats it writes an object with an attribute
+// for each deployment, and for variable formats names are prefixed with deployment name.
+func WriteDeployments(w io.Writer, format string, deployments map[string]Outputs, options *Options) error {
+	if options == nil {
+		options = &Options{}
+	}
+
+	if format == "terraform-json" {
+		values := map[string]interface{}{}
+
+		for name, outputs := range deployments {
+			value, err := terraformJSON(outputs, options)
+			if err != nil {
+				return err
+			}
+
+			values[name] = value
+		}
+
+		return encodeJSON(w, values)
+	}
+
+	values := map[string]cty.Value{}
+
+	for name, outputs := range deployments {
+		if isVariableFormat(format) {
+			for key, value := range outputs.values(options) {
+				values[VariableName(name, key)] = value
+			}
+
+			continue
+		}
+
+		values[name] = cty.ObjectVal(outputs.values(options))
+	}
+
+	return write(w, format, values, options)
+}
+
+// value returns value of output, or SensitiveValue if output is sensitive and sensitive
+// values should not be shown
+func (o *Output) value(options *Options) cty.Value {
+	if o.Sensitive && !options.ShowSensitive {
+		return cty.StringVal(SensitiveValue)
+	}
+
+	return o.Value
+}
+
+// values returns the values of all outputs, with sensitive values replaced
+func (o Outputs) values(options *Options) map[string]cty.Value {
+	values := map[string]cty.Value{}
+
+	for name, output := range o {
+		values[name] = output.value(options)
+	}
+
+	return values
+}
+
+// write writes values to w in format
+func write(w io.Writer, format string, values map[string]cty.Value, options *Options) error {
+	switch format {
+	case "json":
+		return writeJSON(w, cty.ObjectVal(values))
+	case "yaml":
+		return writeYAML(w, cty.ObjectVal(values))
+	case "env":
+		return writeEnv(w, values)
+	case "dotenv":
+		return writeDotenv(w, values)
+	case "export":
+		return writeExport(w, values)
+	case "tfvars":
+		return writeTfvars(w, values)
+	case "github":
+		return writeGithub(w, values, options)
+	default:
+		return formatNotSupported
+	}
+}
+
+// isVariableFormat returns true if format writes a variable for each output, where objects
+// are json encoded instead of flattened
+func isVariableFormat(format string) bool {
+	return format == "dotenv" || format == "export" || format == "github"
+}
+
+// terraformOutput is a single output in same format as `terraform output -json`
+type terraformOutput struct {
+	Sensitive bool        `json:"sensitive"`
+	Type      interface{} `json:"type"`
+	Value     interface{} `json:"value"`
+}
+
+// terraformJSON returns outputs in same format as `terraform output -json`
+func terraformJSON(outputs Outputs, options *Options) (map[string]*terraformOutput, error) {
+	values := map[string]*terraformOutput{}
+
+	for name, output := range outputs {
+		value := output.value(options)
+
+		typeJSON, err := ctyjson.MarshalType(value.Type())
+		if err != nil {
+			return nil, err
+		}
+
+		ty, err := decodeRawJSON(typeJSON)
+		if err != nil {
+			return nil, err
+		}
+
+		decoded, err := decodeJSON(value)
+		if err != nil {
+			return nil, err
+		}
+
+		values[name] = &terraformOutput{
+			Sensitive: output.Sensitive,
+			Type:      ty,
+			Value:     decoded,
+		}
+	}
+
+	return values, nil
+}
+
+// valueString converts a value to string. Primitive values are converted to strings, null
+// to an empty string, while lists, maps and objects are json encoded.
+func valueString(value cty.Value) (string, error) {
+	if value.IsNull() || !value.IsKnown() {
+		return "", nil
+	}
+
+	switch value.Type() {
+	case cty.String:
+		return value.AsString(), nil
+	case cty.Number:
+		return value.AsBigFloat().Text('f', -1), nil
+	case cty.Bool:
+		return fmt.Sprintf("%v", value.True()), nil
+	}
+
+	bytes, err := ctyjson.Marshal(value, value.Type())
+	if err != nil {
+		return "", err
+	}
+
+	return string(bytes), nil
+}
+
+// VariableName returns parts joined with _ as a valid variable name, replacing invalid
+// characters with _
+func VariableName(parts ...string) string {
+	return invalidNameRegexp.ReplaceAllString(strings.Join(parts, "_"), "_")
+}
+
+// sortedKeys returns keys in values sorted, so output is written in same order every time
+func sortedKeys(values map[string]cty.Value) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,185 @@
+package output
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zclconf/go-cty/cty"
+
+	tstrings "github.com/avinor/tau/pkg/helpers/strings"
+)
+
+var (
+	testOutputs = Outputs{
+		"name":  {Value: cty.StringVal("vnet")},
+		"count": {Value: cty.NumberIntVal(3)},
+		"ok":    {Value: cty.True},
+		"empty": {Value: cty.NullVal(cty.String)},
+		"subnets": {Value: cty.ListVal([]cty.Value{
+			cty.ObjectVal(map[string]cty.Value{"id": cty.StringVal("s1"), "size": cty.NumberIntVal(24)}),
+		})},
+		"tags":   {Value: cty.MapVal(map[string]cty.Value{"env": cty.StringVal("prod")})},
+		"secret": {Value: cty.StringVal("p4ss'\"word"), Sensitive: true},
+	}
+)
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		Format        string
+		ShowSensitive bool
+		Expected      string
+	}{
+		{
+			"json",
+			false,
+			`{"count":3,"empty":null,"name":"vnet","ok":true,"secret":"<sensitive>","subnets":[{"id":"s1","size":24}],"tags":{"env":"prod"}}` + "\n",
+		},
+		{
+			"json",
+			true,
+			`{"count":3,"empty":null,"name":"vnet","ok":true,"secret":"p4ss'\"word","subnets":[{"id":"s1","size":24}],"tags":{"env":"prod"}}` + "\n",
+		},
+		{
+			"yaml",
+			false,
+			"count: 3\nempty: null\nname: vnet\nok: true\nsecret: <sensitive>\nsubnets:\n- id: s1\n  size: 24\ntags:\n  env: prod\n",
+		},
+		{
+			"env",
+			false,
+			"TAU_COUNT=\"3\"\nTAU_EMPTY=\"\"\nTAU_NAME=\"vnet\"\nTAU_OK=\"true\"\nTAU_SECRET=\"<sensitive>\"\nTAU_SUBNETS_0_ID=\"s1\"\nTAU_SUBNETS_0_SIZE=\"24\"\nTAU_TAGS_ENV=\"prod\"\n",
+		},
+		{
+			"dotenv",
+			true,
+			"TAU_COUNT=\"3\"\nTAU_EMPTY=\"\"\nTAU_NAME=\"vnet\"\nTAU_OK=\"true\"\nTAU_SECRET=\"p4ss'\\\"word\"\nTAU_SUBNETS=\"[{\\\"id\\\":\\\"s1\\\",\\\"size\\\":24}]\"\nTAU_TAGS=\"{\\\"env\\\":\\\"prod\\\"}\"\n",
+		},
+		{
+			"export",
+			true,
+			"export TAU_COUNT='3'\nexport TAU_EMPTY=''\nexport TAU_NAME='vnet'\nexport TAU_OK='true'\nexport TAU_SECRET='p4ss'\\''\"word'\nexport TAU_SUBNETS='[{\"id\":\"s1\",\"size\":24}]'\nexport TAU_TAGS='{\"env\":\"prod\"}'\n",
+		},
+		{
+			"github",
+			false,
+			"count=3\nempty=\nname=vnet\nok=true\nsecret=<sensitive>\nsubnets=[{\"id\":\"s1\",\"size\":24}]\ntags={\"env\":\"prod\"}\n",
+		},
+		{
+			"tfvars",
+			false,
+			"count  = 3\nempty  = null\nname   = \"vnet\"\nok     = true\nsecret = \"<sensitive>\"\nsubnets = [{\n  id   = \"s1\"\n  size = 24\n}]\ntags = {\n  env = \"prod\"\n}\n",
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			var buf bytes.Buffer
+
+			err := Write(&buf, test.Format, testOutputs, &Options{ShowSensitive: test.ShowSensitive})
+			assert.NoError(t, err)
+			assert.Equal(t, test.Expected, buf.String())
+		})
+	}
+}
+
+func TestWriteTerraformJSON(t *testing.T) {
+	outputs := Outputs{
+		"name":   {Value: cty.StringVal("vnet")},
+		"size":   {Value: cty.NumberIntVal(24)},
+		"secret": {Value: cty.NumberIntVal(42), Sensitive: true},
+	}
+
+	var buf bytes.Buffer
+
+	err := Write(&buf, "terraform-json", outputs, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":{"sensitive":false,"type":"string","value":"vnet"},"secret":{"sensitive":true,"type":"string","value":"<sensitive>"},"size":{"sensitive":false,"type":"number","value":24}}`+"\n", buf.String())
+}
+
+func TestWriteDeployments(t *testing.T) {
+	deployments := map[string]Outputs{
+		"vnet":    {"id": {Value: cty.StringVal("v1")}},
+		"aks.dev": {"id": {Value: cty.StringVal("a1")}, "port": {Value: cty.NumberIntVal(443)}},
+	}
+
+	tests := []struct {
+		Format   string
+		Expected string
+	}{
+		{"json", `{"aks.dev":{"id":"a1","port":443},"vnet":{"id":"v1"}}` + "\n"},
+		{"terraform-json", `{"aks.dev":{"id":{"sensitive":false,"type":"string","value":"a1"},"port":{"sensitive":false,"type":"number","value":443}},"vnet":{"id":{"sensitive":false,"type":"string","value":"v1"}}}` + "\n"},
+		{"env", "TAU_AKS_DEV_ID=\"a1\"\nTAU_AKS_DEV_PORT=\"443\"\nTAU_VNET_ID=\"v1\"\n"},
+		{"export", "export TAU_AKS_DEV_ID='a1'\nexport TAU_AKS_DEV_PORT='443'\nexport TAU_VNET_ID='v1'\n"},
+		{"tfvars", "aks_dev = {\n  id   = \"a1\"\n  port = 443\n}\nvnet = {\n  id = \"v1\"\n}\n"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			var buf bytes.Buffer
+
+			err := WriteDeployments(&buf, test.Format, deployments, nil)
+			assert.NoError(t, err)
+			assert.Equal(t, test.Expected, buf.String())
+		})
+	}
+}
+
+func TestWriteParsesBack(t *testing.T) {
+	outputs := Outputs{
+		"multi":  {Value: cty.StringVal("line 1\nline \"2\"\\")},
+		"quotes": {Value: cty.StringVal(`it's "quoted"`)},
+	}
+	expected := map[string]string{
+		"TAU_MULTI":  "line 1\nline \"2\"\\",
+		"TAU_QUOTES": `it's "quoted"`,
+	}
+
+	var dotenv bytes.Buffer
+	assert.NoError(t, Write(&dotenv, "dotenv", outputs, nil))
+
+	values, err := tstrings.ParseDotenvVars(dotenv.String())
+	assert.NoError(t, err)
+	assert.Equal(t, expected, values)
+
+	var export bytes.Buffer
+	assert.NoError(t, Write(&export, "export", outputs, nil))
+
+	values, err = tstrings.ParseExportVars(export.String())
+	assert.NoError(t, err)
+	assert.Equal(t, expected, values)
+}
+
+func TestWriteGithubOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tau-output")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "github_output")
+	assert.NoError(t, ioutil.WriteFile(file, []byte("existing=1\n"), 0644))
+
+	outputs := Outputs{
+		"id":    {Value: cty.StringVal("v1")},
+		"multi": {Value: cty.StringVal("a\nEOF\nb")},
+	}
+
+	var buf bytes.Buffer
+	assert.NoError(t, Write(&buf, "github", outputs, &Options{GithubOutput: file}))
+	assert.Empty(t, buf.String())
+
+	content, err := ioutil.ReadFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, "existing=1\nid=v1\nmulti<<EOF_1\na\nEOF\nb\nEOF_1\n", string(content))
+}
+
+func TestWriteInvalidFormat(t *testing.T) {
+	var buf bytes.Buffer
+
+	assert.Error(t, Write(&buf, "xml", testOutputs, nil))
+	assert.False(t, IsValidFormat("xml"))
+	assert.True(t, IsValidFormat("tfvars"))
+}
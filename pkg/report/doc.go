@@ -0,0 +1,3 @@
+// Package report collects the result of running a command for each deployment, so it
+// can be written as a JUnit or JSON report that CI systems can show per deployment.
+package report
@@ -0,0 +1,64 @@
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/avinor/tau/pkg/terraform/def"
+)
+
+// jsonReport is format of report written as json, durations are in seconds
+type jsonReport struct {
+	Command     string            `json:"command"`
+	Started     time.Time         `json:"started"`
+	Duration    float64           `json:"duration"`
+	Success     int               `json:"success"`
+	Failed      int               `json:"failed"`
+	Skipped     int               `json:"skipped"`
+	NoPlan      int               `json:"no_plan"`
+	Deployments []*jsonDeployment `json:"deployments"`
+}
+
+type jsonDeployment struct {
+	Name     string           `json:"name"`
+	Status   Status           `json:"status"`
+	Message  string           `json:"message,omitempty"`
+	Command  string           `json:"command"`
+	Started  time.Time        `json:"started"`
+	Duration float64          `json:"duration"`
+	Stderr   string           `json:"stderr,omitempty"`
+	Plan     *def.PlanSummary `json:"plan,omitempty"`
+}
+
+// WriteJSON writes the report as json to writer
+func (r *Report) WriteJSON(writer io.Writer) error {
+	report := &jsonReport{
+		Command:     r.Command,
+		Started:     r.Started,
+		Duration:    r.Duration.Seconds(),
+		Success:     r.Count(Success),
+		Failed:      r.Count(Failed),
+		Skipped:     r.Count(Skipped),
+		NoPlan:      r.Count(NoPlan),
+		Deployments: []*jsonDeployment{},
+	}
+
+	for _, d := range r.Deployments {
+		report.Deployments = append(report.Deployments, &jsonDeployment{
+			Name:     d.Name,
+			Status:   d.Status,
+			Message:  d.Message,
+			Command:  d.Command,
+			Started:  d.Started,
+			Duration: d.Duration.Seconds(),
+			Stderr:   d.Stderr,
+			Plan:     d.Plan,
+		})
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(report)
+}
@@ -0,0 +1,109 @@
+package report
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+)
+
+// junitTestSuites is the root element of a JUnit report
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+	SystemErr string        `xml:"system-err,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+}
+
+// WriteJUnit writes the report in JUnit xml format to writer. Each deployment is a test
+// case, deployments skipped or without a plan are reported as skipped.
+func (r *Report) WriteJUnit(writer io.Writer) error {
+	suite := junitTestSuite{
+		Name:      fmt.Sprintf("tau %s", r.Command),
+		Tests:     len(r.Deployments),
+		Failures:  r.Count(Failed),
+		Skipped:   r.Count(Skipped) + r.Count(NoPlan),
+		Time:      seconds(r.Duration.Seconds()),
+		Timestamp: r.Started.Format("2006-01-02T15:04:05"),
+	}
+
+	for _, d := range r.Deployments {
+		tc := junitTestCase{
+			Name:      d.Name,
+			ClassName: fmt.Sprintf("tau.%s", d.Command),
+			Time:      seconds(d.Duration.Seconds()),
+			SystemErr: d.Stderr,
+		}
+
+		switch d.Status {
+		case Failed:
+			tc.Failure = &junitMessage{Message: d.Message}
+		case Skipped, NoPlan:
+			message := d.Message
+			if message == "" {
+				message = string(d.Status)
+			}
+			tc.Skipped = &junitMessage{Message: message}
+		}
+
+		if d.Plan != nil {
+			tc.SystemOut = fmt.Sprintf("Plan: %v to add, %v to change, %v to destroy.", d.Plan.Add, d.Plan.Change, d.Plan.Destroy)
+		}
+
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	suites := &junitTestSuites{
+		Name:     "tau",
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	if _, err := io.WriteString(writer, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(writer, "\n")
+	return err
+}
+
+// seconds formats seconds with 3 decimals
+func seconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
@@ -0,0 +1,179 @@
+package report
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/avinor/tau/pkg/terraform/def"
+)
+
+// Status of a deployment in report
+type Status string
+
+const (
+	// Success when command completed successfully for deployment
+	Success Status = "success"
+
+	// Failed when command failed for deployment
+	Failed Status = "failed"
+
+	// Skipped when deployment was skipped, for instance because dependencies could not be resolved
+	Skipped Status = "skipped"
+
+	// NoPlan when there was no plan to apply for deployment
+	NoPlan Status = "no plan"
+)
+
+var (
+	// formatNotSupported is returned if report file does not have a supported extension
+	formatNotSupported = errors.Errorf("report has to be a .xml (junit) or .json file")
+)
+
+// Report is the result of running a command for all deployments
+type Report struct {
+	Command     string
+	Started     time.Time
+	Duration    time.Duration
+	Deployments []*Deployment
+
+	lock sync.Mutex
+}
+
+// Deployment is the result of running a command for one deployment. Command is the
+// terraform command executed and Stderr is all output to stderr, with secrets redacted.
+type Deployment struct {
+	Name     string
+	Status   Status
+	Message  string
+	Command  string
+	Started  time.Time
+	Duration time.Duration
+	Stderr   string
+	Plan     *def.PlanSummary
+}
+
+// New returns a new report for command
+func New(command string) *Report {
+	return &Report{
+		Command:     command,
+		Started:     time.Now(),
+		Deployments: []*Deployment{},
+	}
+}
+
+// Start adds a deployment to report and starts timing it. If deployment already
+// exists in report it is restarted, resetting status.
+func (r *Report) Start(name, command string) *Deployment {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	deployment := r.find(name)
+	if deployment == nil {
+		deployment = &Deployment{Name: name}
+		r.Deployments = append(r.Deployments, deployment)
+	}
+
+	deployment.Status = ""
+	deployment.Message = ""
+	deployment.Command = command
+	deployment.Started = time.Now()
+
+	return deployment
+}
+
+// Get returns the deployment with name, or nil if it is not in report
+func (r *Report) Get(name string) *Deployment {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	return r.find(name)
+}
+
+// Finish stops timing the report
+func (r *Report) Finish() {
+	r.Duration = time.Since(r.Started)
+}
+
+// Count returns number of deployments with status
+func (r *Report) Count(status Status) int {
+	count := 0
+	for _, deployment := range r.Deployments {
+		if deployment.Status == status {
+			count++
+		}
+	}
+
+	return count
+}
+
+// ValidatePath checks that report can be written to path, it has to have a supported extension
+func ValidatePath(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xml", ".json":
+		return nil
+	default:
+		return formatNotSupported
+	}
+}
+
+// Write report to path. Format is decided by extension, .xml for JUnit or .json
+func (r *Report) Write(path string) error {
+	if err := ValidatePath(path); err != nil {
+		return err
+	}
+
+	write := r.WriteJSON
+	if strings.ToLower(filepath.Ext(path)) == ".xml" {
+		write = r.WriteJUnit
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return write(file)
+}
+
+func (r *Report) find(name string) *Deployment {
+	for _, deployment := range r.Deployments {
+		if deployment.Name == name {
+			return deployment
+		}
+	}
+
+	return nil
+}
+
+// SetStatus sets status of deployment, with a message describing why
+func (d *Deployment) SetStatus(status Status, msg string, args ...interface{}) {
+	d.Status = status
+	d.Message = fmt.Sprintf(msg, args...)
+}
+
+// Finish stops timing the deployment and sets status. If err is set it failed, otherwise
+// it succeeded unless status has been set already.
+func (d *Deployment) Finish(err error, stderr string) {
+	d.Duration = time.Since(d.Started)
+	d.Stderr = stderr
+
+	if err != nil {
+		d.SetStatus(Failed, "%s", err)
+		return
+	}
+
+	if d.Status == "" {
+		d.SetStatus(Success, "")
+	}
+}
@@ -0,0 +1,106 @@
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/avinor/tau/pkg/terraform/def"
+)
+
+func newTestReport() *Report {
+	r := New("apply")
+
+	r.Start("a.hcl", "apply").Finish(nil, "")
+
+	b := r.Start("b.hcl", "apply")
+	b.Plan = &def.PlanSummary{Add: 1, Change: 2, Destroy: 3}
+	b.Finish(errors.Errorf("apply command exited with exit code 1"), "Error: something failed")
+
+	c := r.Start("c.hcl", "apply")
+	c.SetStatus(Skipped, "dependencies could not be resolved")
+	c.Finish(nil, "")
+
+	d := r.Start("d.hcl", "apply")
+	d.SetStatus(NoPlan, "")
+	d.Finish(nil, "")
+
+	r.Finish()
+
+	return r
+}
+
+func TestStatus(t *testing.T) {
+	r := newTestReport()
+
+	tests := []struct {
+		Name     string
+		Expected Status
+	}{
+		{"a.hcl", Success},
+		{"b.hcl", Failed},
+		{"c.hcl", Skipped},
+		{"d.hcl", NoPlan},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			assert.Equal(t, test.Expected, r.Get(test.Name).Status)
+		})
+	}
+
+	// Restarting a deployment resets status
+	r.Start("b.hcl", "apply").Finish(nil, "")
+	assert.Equal(t, Success, r.Get("b.hcl").Status)
+	assert.Len(t, r.Deployments, 4)
+}
+
+func TestWriteJSON(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	assert.NoError(t, newTestReport().WriteJSON(buffer))
+
+	var report jsonReport
+	assert.NoError(t, json.Unmarshal(buffer.Bytes(), &report))
+
+	assert.Equal(t, "apply", report.Command)
+	assert.Equal(t, 1, report.Success)
+	assert.Equal(t, 1, report.Failed)
+	assert.Equal(t, 1, report.Skipped)
+	assert.Equal(t, 1, report.NoPlan)
+	assert.Len(t, report.Deployments, 4)
+	assert.Equal(t, "Error: something failed", report.Deployments[1].Stderr)
+	assert.Equal(t, &def.PlanSummary{Add: 1, Change: 2, Destroy: 3}, report.Deployments[1].Plan)
+}
+
+func TestWriteJUnit(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	assert.NoError(t, newTestReport().WriteJUnit(buffer))
+
+	output := buffer.String()
+
+	tests := []string{
+		`<testsuites name="tau" tests="4" failures="1" skipped="2"`,
+		`<testsuite name="tau apply" tests="4" failures="1" skipped="2"`,
+		`<testcase name="a.hcl" classname="tau.apply"`,
+		`<failure message="apply command exited with exit code 1"></failure>`,
+		`<system-out>Plan: 1 to add, 2 to change, 3 to destroy.</system-out>`,
+		`<system-err>Error: something failed</system-err>`,
+		`<skipped message="dependencies could not be resolved"></skipped>`,
+		`<skipped message="no plan"></skipped>`,
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			assert.True(t, strings.Contains(output, test), "expected %s in %s", test, output)
+		})
+	}
+}
+
+func TestWriteUnsupportedFormat(t *testing.T) {
+	assert.Equal(t, formatNotSupported, New("plan").Write("report.txt"))
+}
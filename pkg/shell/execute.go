@@ -1,34 +1,98 @@
 package shell
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"os/exec"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
-	"github.com/go-cmd/cmd"
-	"github.com/go-errors/errors"
-
 	"github.com/avinor/tau/pkg/helpers/ui"
 )
 
-// Execute a shell command
-func Execute(options *Options, command string, args ...string) error {
+const (
+	// KillDelay is how long to wait for a command to stop after it has been interrupted,
+	// before it is killed
+	KillDelay = 30 * time.Second
+
+	// OutputDelay is how long to wait for output after command exited, output could still
+	// be written by child processes of command
+	OutputDelay = 2 * time.Second
+)
+
+// ExitError is returned when command exits with a non-zero exit code. Signal is the
+// signal that stopped the command, either received by command or forwarded to it by tau
+// when interrupted, nil if command was not stopped by a signal.
+type ExitError struct {
+	Command  string
+	ExitCode int
+	Signal   os.Signal
+}
+
+// Error returns the error message
+func (e *ExitError) Error() string {
+	if e.Signal != nil {
+		return fmt.Sprintf("%s command stopped by signal %s, exit code %v", e.Command, e.Signal, e.ExitCode)
+	}
+
+	return fmt.Sprintf("%s command exited with exit code %v", e.Command, e.ExitCode)
+}
+
+// TimeoutError is returned when command does not complete within timeout
+type TimeoutError struct {
+	Command string
+	Timeout time.Duration
+}
+
+// Error returns the error message
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s command timed out after %s", e.Command, e.Timeout)
+}
+
+// Execute a shell command. When context is done the command is interrupted, so it can
+// stop gracefully, and killed if it has not stopped within KillDelay. If options has a
+// timeout the command is interrupted if it does not complete within timeout and a
+// TimeoutError is returned. If options has a retry policy the command is retried if it
+// fails with a transient error.
+func Execute(ctx context.Context, options *Options, command string, args ...string) error {
 	if options == nil {
 		options = &Options{}
 	}
 
-	// Disable output buffering, enable streaming
-	cmdOptions := cmd.Options{
-		Buffered:  false,
-		Streaming: true,
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return executeWithRetry(ctx, options, command, args, func(options *Options) error {
+		return execute(ctx, options, command, args...)
+	})
+}
+
+// execute runs the command once
+func execute(ctx context.Context, options *Options, command string, args ...string) error {
+	parent := ctx
+
+	if options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(parent, options.Timeout)
+		defer cancel()
 	}
 
-	execCmd := cmd.NewCmdOptions(cmdOptions, command, args...)
+	execCmd := exec.Command(command, args...)
 	if options.WorkingDirectory != "" {
 		execCmd.Dir = options.WorkingDirectory
 	}
 
+	// Run in separate process group so signals from terminal are not sent directly to
+	// command, tau forwards them instead
+	execCmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
 	execCmd.Env = os.Environ()
 
 	if len(options.Env) > 0 {
@@ -39,35 +103,182 @@ func Execute(options *Options, command string, args ...string) error {
 	}
 
 	ui.Debug("environment variables: %#v", execCmd.Env)
-	ui.Debug("command: %s %s", execCmd.Name, strings.Join(execCmd.Args, " "))
+	ui.Debug("command: %s %s", command, strings.Join(args, " "))
+
+	log := currentLog()
+	if log != nil {
+		log.AddSecretsFromEnv(options.Env)
+		log.Command(command, args...)
+
+		if options.SensitiveStdout {
+			log.Write("# stdout not logged, it can contain secrets")
+		}
+	}
+
+	// Use pipes without copying, so waiting for command does not wait for child processes
+	// that inherited stdout or stderr
+	stdoutReader, stdoutWriter, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer stdoutReader.Close()
+
+	stderrReader, stderrWriter, err := os.Pipe()
+	if err != nil {
+		stdoutWriter.Close()
+		return err
+	}
+	defer stderrReader.Close()
+
+	execCmd.Stdout = stdoutWriter
+	execCmd.Stderr = stderrWriter
+
+	err = execCmd.Start()
+
+	stdoutWriter.Close()
+	stderrWriter.Close()
+
+	if err != nil {
+		logLine(log, fmt.Sprintf("# failed: %s", err))
+		return err
+	}
+
+	running.add(execCmd)
+	defer running.remove(execCmd)
+
+	// Process STDOUT and STDERR lines streaming from command, lines are processed one at
+	// the time so processors do not have to be thread safe
+	var lineLock sync.Mutex
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go readLines(stdoutReader, &wg, func(line string) {
+		lineLock.Lock()
+		defer lineLock.Unlock()
 
-	// Print STDOUT and STDERR lines streaming from Cmd
+		if !options.SensitiveStdout {
+			logLine(log, line)
+		}
+		processLine(options.Stdout, line)
+	})
+
+	go readLines(stderrReader, &wg, func(line string) {
+		lineLock.Lock()
+		defer lineLock.Unlock()
+
+		if log != nil {
+			log.WriteStderr(line)
+		}
+		processLine(options.Stderr, line)
+	})
+
+	done := make(chan error, 1)
 	go func() {
-		for {
-			select {
-			case line := <-execCmd.Stdout:
-				processLine(options.Stdout, line)
-			case line := <-execCmd.Stderr:
-				processLine(options.Stderr, line)
+		err := execCmd.Wait()
+		waitForOutput(&wg, stdoutReader, stderrReader)
+		done <- err
+	}()
+
+	var interrupted os.Signal
+	var killTimer <-chan time.Time
+	timedOut := false
+	ctxDone := ctx.Done()
+
+	for {
+		select {
+		case err := <-done:
+			if err == nil {
+				logLine(log, "# exit code 0")
+				return nil
+			}
+
+			if timedOut {
+				logLine(log, fmt.Sprintf("# timed out after %s", options.Timeout))
+
+				return &TimeoutError{
+					Command: command,
+					Timeout: options.Timeout,
+				}
 			}
+
+			return exitError(command, err, interrupted, log)
+		case <-ctxDone:
+			// Only interrupt once, signals received later are forwarded directly
+			ctxDone = nil
+			timedOut = options.Timeout > 0 && parent.Err() == nil
+
+			interrupted = interruptSignal()
+			ui.Debug("interrupting command %s with %s", command, interrupted)
+			signalProcess(execCmd, interrupted)
+
+			killTimer = time.After(KillDelay)
+		case <-killTimer:
+			ui.Warn("Command %s did not stop within %s, killing it", command, KillDelay)
+			signalProcess(execCmd, syscall.SIGKILL)
 		}
-	}()
+	}
+}
+
+// exitError converts the error from waiting for command to an ExitError, with signal that
+// stopped command if it was stopped by a signal or interrupted
+func exitError(command string, err error, interrupted os.Signal, log *Log) error {
+	exitErr, ok := err.(*exec.ExitError)
+	if !ok {
+		logLine(log, fmt.Sprintf("# failed: %s", err))
+		return err
+	}
+
+	result := &ExitError{
+		Command:  command,
+		ExitCode: exitErr.ExitCode(),
+		Signal:   interrupted,
+	}
+
+	if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		result.Signal = status.Signal()
+		result.ExitCode = 128 + int(status.Signal())
+	}
 
-	status := <-execCmd.Start()
+	logLine(log, fmt.Sprintf("# %s", result.Error()))
+
+	return result
+}
+
+// waitForOutput waits until all output has been processed. If child processes still
+// have output open after OutputDelay the readers are closed, so reading stops.
+func waitForOutput(wg *sync.WaitGroup, readers ...io.Closer) {
+	processed := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(processed)
+	}()
 
-	for len(execCmd.Stdout) > 0 || len(execCmd.Stderr) > 0 {
-		time.Sleep(10 * time.Millisecond)
+	select {
+	case <-processed:
+		return
+	case <-time.After(OutputDelay):
 	}
 
-	if status.Error != nil {
-		return status.Error
+	for _, reader := range readers {
+		reader.Close()
 	}
 
-	if status.Exit != 0 {
-		return errors.Errorf("%s command exited with exit code %v", command, status.Exit)
+	<-processed
+}
+
+// readLines reads lines from reader and calls fn for each line until reader is closed
+func readLines(reader io.Reader, wg *sync.WaitGroup, fn func(line string)) {
+	defer wg.Done()
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		fn(scanner.Text())
 	}
 
-	return nil
+	// Make sure command does not block writing to a full pipe if line was too long
+	io.Copy(ioutil.Discard, reader) //nolint:errcheck
 }
 
 func processLine(processors []OutputProcessor, line string) {
@@ -77,3 +288,12 @@ func processLine(processors []OutputProcessor, line string) {
 		}
 	}
 }
+
+// logLine writes line to log, if logging
+func logLine(log *Log, line string) {
+	if log == nil {
+		return
+	}
+
+	log.Write("%s", line)
+}
@@ -0,0 +1,150 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// lines collects all lines written to it
+type lines []string
+
+func (l *lines) Write(line string) bool {
+	*l = append(*l, line)
+	return true
+}
+
+func TestExecute(t *testing.T) {
+	tests := []struct {
+		Script   string
+		Timeout  time.Duration
+		Stdout   []string
+		Stderr   []string
+		ExitCode int
+		Signal   syscall.Signal
+		TimedOut bool
+	}{
+		{"echo one; echo two", 0, []string{"one", "two"}, nil, 0, 0, false},
+		{"echo out; echo err >&2; exit 3", 0, []string{"out"}, []string{"err"}, 3, 0, false},
+		{"kill -TERM $$", 0, nil, nil, 128 + int(syscall.SIGTERM), syscall.SIGTERM, false},
+		{"sleep 10", 100 * time.Millisecond, nil, nil, 0, 0, true},
+		{"trap 'echo stopping; exit 1' INT; sleep 10 & wait", 100 * time.Millisecond, []string{"stopping"}, nil, 0, 0, true},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			var stdout, stderr lines
+
+			options := &Options{
+				Stdout:  Processors(&stdout),
+				Stderr:  Processors(&stderr),
+				Timeout: test.Timeout,
+			}
+
+			err := Execute(context.Background(), options, "sh", "-c", test.Script)
+
+			assert.Equal(t, test.Stdout, []string(stdout))
+			assert.Equal(t, test.Stderr, []string(stderr))
+
+			switch {
+			case test.TimedOut:
+				assert.IsType(t, &TimeoutError{}, err)
+			case test.ExitCode == 0:
+				assert.NoError(t, err)
+			default:
+				exitErr, ok := err.(*ExitError)
+				if assert.True(t, ok, "expected ExitError, got %s", err) {
+					assert.Equal(t, test.ExitCode, exitErr.ExitCode)
+
+					if test.Signal != 0 {
+						assert.Equal(t, test.Signal, exitErr.Signal)
+					} else {
+						assert.Nil(t, exitErr.Signal)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestExecuteCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+
+	start := time.Now()
+	err := Execute(ctx, nil, "sleep", "10")
+
+	assert.True(t, time.Since(start) < 5*time.Second)
+
+	exitErr, ok := err.(*ExitError)
+	if assert.True(t, ok, "expected ExitError, got %s", err) {
+		assert.Equal(t, syscall.SIGINT, exitErr.Signal)
+	}
+}
+
+func TestExecuteDoesNotLeakGoroutines(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, Execute(context.Background(), nil, "true"))
+	}
+
+	// Give goroutines time to exit
+	time.Sleep(100 * time.Millisecond)
+
+	assert.True(t, runtime.NumGoroutine() <= before+1, "goroutines before %v, after %v", before, runtime.NumGoroutine())
+}
+
+func TestExecuteWithRetry(t *testing.T) {
+	tests := []struct {
+		Script   string
+		Attempts int
+		Runs     int
+		Error    bool
+	}{
+		{"echo run; exit 0", 3, 1, false},
+		{"echo run; echo 'Error acquiring the state lock' >&2; exit 1", 3, 3, true},
+		{"echo run; echo 'Error: invalid resource' >&2; exit 1", 3, 1, true},
+		{"echo run; echo 'Error acquiring the state lock' >&2; exit 1", 1, 1, true},
+		{"echo run; if [ -f $MARKER ]; then exit 0; fi; touch $MARKER; echo 'Error acquiring the state lock' >&2; exit 1", 3, 2, false},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "tau-retry")
+			assert.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			var stdout lines
+
+			options := &Options{
+				Stdout: Processors(&stdout),
+				Env:    map[string]string{"MARKER": filepath.Join(dir, "marker")},
+				Retry: &RetryPolicy{
+					Patterns:    []*regexp.Regexp{regexp.MustCompile("Error acquiring the state lock")},
+					MaxAttempts: test.Attempts,
+					Backoff:     time.Millisecond,
+				},
+			}
+
+			err = Execute(context.Background(), options, "sh", "-c", test.Script)
+
+			assert.Len(t, stdout, test.Runs)
+
+			if test.Error {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package shell
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Redacted replaces secrets written to log
+	Redacted = "***"
+
+	// minSecretLength is minimum length of a value before it is redacted, to avoid
+	// redacting short values like "1" or "true" everywhere in log
+	minSecretLength = 4
+)
+
+var (
+	// secretNameRegexp matches names of environment variables and arguments that are secrets
+	secretNameRegexp = regexp.MustCompile(`(?i)(secret|password|passwd|token|key|credential|sas)`)
+
+	// current is the log all commands are written to, nil if not logging
+	current *Log
+
+	currentLock sync.RWMutex
+)
+
+// Log writes commands executed and their output to a file. Values of secrets are
+// redacted before written to file.
+type Log struct {
+	Path string
+
+	writer  io.WriteCloser
+	secrets []string
+	stderr  []string
+	lock    sync.Mutex
+}
+
+// OpenLog creates the log file in path, including the directory if it does not exist
+func OpenLog(path string) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Log{
+		Path:   path,
+		writer: file,
+	}, nil
+}
+
+// SetLog sets the log all executed commands are written to and returns a function
+// that restores previous log.
+func SetLog(log *Log) func() {
+	currentLock.Lock()
+	defer currentLock.Unlock()
+
+	previous := current
+	current = log
+
+	return func() {
+		currentLock.Lock()
+		defer currentLock.Unlock()
+
+		current = previous
+	}
+}
+
+// currentLog returns the log commands are written to, nil if not logging
+func currentLog() *Log {
+	currentLock.RLock()
+	defer currentLock.RUnlock()
+
+	return current
+}
+
+// AddSecretsFromEnv adds values of environment variables that have a name indicating it
+// is a secret, so they are redacted in log
+func (l *Log) AddSecretsFromEnv(env map[string]string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	for name, value := range env {
+		if !secretNameRegexp.MatchString(name) || len(value) < minSecretLength {
+			continue
+		}
+
+		l.secrets = append(l.secrets, value)
+	}
+
+	// Replace longest secrets first, in case one secret contains another
+	sort.Slice(l.secrets, func(i, j int) bool {
+		return len(l.secrets[i]) > len(l.secrets[j])
+	})
+}
+
+// Command writes the command line to log. Arguments in format name=value are redacted
+// if name indicates it is a secret.
+func (l *Log) Command(command string, args ...string) {
+	line := []string{command}
+
+	for _, arg := range args {
+		line = append(line, redactArgument(arg))
+	}
+
+	l.Write("%s $ %s", time.Now().Format(time.RFC3339), strings.Join(line, " "))
+}
+
+// Write a line to log with secrets redacted
+func (l *Log) Write(msg string, args ...interface{}) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	fmt.Fprintln(l.writer, l.redact(fmt.Sprintf(msg, args...)))
+}
+
+// WriteStderr writes a line from stderr to log and keeps it, so all output to stderr
+// can be retrieved with Stderr
+func (l *Log) WriteStderr(line string) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	line = l.redact(line)
+	l.stderr = append(l.stderr, line)
+
+	fmt.Fprintln(l.writer, line)
+}
+
+// Stderr returns all lines written to stderr by commands, with secrets redacted
+func (l *Log) Stderr() string {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	return strings.Join(l.stderr, "\n")
+}
+
+// redact replaces all secrets in line
+func (l *Log) redact(line string) string {
+	for _, secret := range l.secrets {
+		line = strings.ReplaceAll(line, secret, Redacted)
+	}
+
+	return line
+}
+
+// Close the log file
+func (l *Log) Close() error {
+	return l.writer.Close()
+}
+
+// IsSecretName returns true if name of a variable or argument indicates it is a secret
+func IsSecretName(name string) bool {
+	return secretNameRegexp.MatchString(name)
+}
+
+// redactArgument redacts value of argument in format name=value, or -option=name=value,
+// if name indicates it is a secret
+func redactArgument(arg string) string {
+	parts := strings.Split(arg, "=")
+
+	for i := 0; i < len(parts)-1; i++ {
+		if secretNameRegexp.MatchString(parts[i]) {
+			return strings.Join(parts[:i+1], "=") + "=" + Redacted
+		}
+	}
+
+	return arg
+}
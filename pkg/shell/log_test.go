@@ -0,0 +1,83 @@
+package shell
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactArgument(t *testing.T) {
+	tests := []struct {
+		Arg      string
+		Expected string
+	}{
+		{"-input=false", "-input=false"},
+		{"-var=client_secret=abc==", "-var=client_secret=***"},
+		{"--password=hunter2", "--password=***"},
+		{"-backend-config=storage_account_name=tau", "-backend-config=storage_account_name=tau"},
+		{"plan", "plan"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			assert.Equal(t, test.Expected, redactArgument(test.Arg))
+		})
+	}
+}
+
+func TestLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tau-log")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	log, err := OpenLog(filepath.Join(dir, "logs", "plan.log"))
+	assert.NoError(t, err)
+
+	log.AddSecretsFromEnv(map[string]string{
+		"ARM_CLIENT_SECRET": "supersecret",
+		"ARM_TOKEN":         "1",
+		"ARM_SUBSCRIPTION":  "subscription",
+	})
+
+	log.Write("secret is supersecret in subscription")
+	log.Write("short %s", "1")
+	log.WriteStderr("Error: supersecret is invalid")
+	assert.NoError(t, log.Close())
+
+	assert.Equal(t, "Error: *** is invalid", log.Stderr())
+
+	content, err := ioutil.ReadFile(log.Path)
+	assert.NoError(t, err)
+	assert.Equal(t, "secret is *** in subscription\nshort 1\nError: *** is invalid\n", string(content))
+}
+
+func TestExecuteSensitiveStdout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tau-log")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	log, err := OpenLog(filepath.Join(dir, "logs", "output.log"))
+	assert.NoError(t, err)
+	defer SetLog(log)()
+
+	var stdout lines
+	options := &Options{
+		Stdout:          Processors(&stdout),
+		SensitiveStdout: true,
+	}
+
+	assert.NoError(t, Execute(context.Background(), options, "sh", "-c", "echo password=hunter2; echo failed >&2"))
+	assert.NoError(t, log.Close())
+
+	assert.Equal(t, []string{"password=hunter2"}, []string(stdout))
+
+	content, err := ioutil.ReadFile(log.Path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(content), "hunter2")
+	assert.Contains(t, string(content), "failed")
+}
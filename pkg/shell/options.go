@@ -1,11 +1,21 @@
 package shell
 
-// Options when running shell command
+import (
+	"time"
+)
+
+// Options when running shell command. If Timeout is set command will be stopped
+// if it has not completed within timeout. If Retry is set command is retried if it
+// fails with a transient error, Timeout applies to each attempt. If SensitiveStdout is set
+// output to stdout is not written to log, for commands that output secrets.
 type Options struct {
 	WorkingDirectory string
 	Stdout           []OutputProcessor
 	Stderr           []OutputProcessor
 	Env              map[string]string
+	Timeout          time.Duration
+	Retry            *RetryPolicy
+	SensitiveStdout  bool
 }
 
 // OutputProcessor can process a line from command output, does not separate between
@@ -0,0 +1,93 @@
+package shell
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/avinor/tau/pkg/helpers/ui"
+)
+
+// RetryPolicy retries commands that fail with transient errors. If command fails and a line
+// written to stderr matches one of the patterns it is run again, until it has been run
+// MaxAttempts times. Time to wait is doubled for every retry, starting with Backoff.
+type RetryPolicy struct {
+	Patterns    []*regexp.Regexp
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+// retryMatcher is an OutputProcessor that checks all lines against retry patterns and
+// remembers first line that matched
+type retryMatcher struct {
+	patterns []*regexp.Regexp
+	matched  string
+}
+
+// Write checks if line matches any of the patterns, always continues to next processor
+func (m *retryMatcher) Write(line string) bool {
+	if m.matched != "" {
+		return true
+	}
+
+	for _, pattern := range m.patterns {
+		if pattern.MatchString(line) {
+			m.matched = line
+			break
+		}
+	}
+
+	return true
+}
+
+// executeWithRetry runs execute and retries it as long as it fails with a line on stderr
+// matching the retry patterns, until max attempts is reached or context is done.
+func executeWithRetry(ctx context.Context, options *Options, command string, args []string, execute func(options *Options) error) error {
+	policy := options.Retry
+	if policy == nil || policy.MaxAttempts <= 1 || len(policy.Patterns) == 0 {
+		return execute(options)
+	}
+
+	backoff := policy.Backoff
+
+	var err error
+	for attempt := 1; attempt <= policy.MaxAttempts; attempt++ {
+		matcher := &retryMatcher{patterns: policy.Patterns}
+
+		attemptOptions := *options
+		attemptOptions.Stderr = append([]OutputProcessor{matcher}, options.Stderr...)
+
+		if err = execute(&attemptOptions); err == nil {
+			return nil
+		}
+
+		if matcher.matched == "" || attempt == policy.MaxAttempts || ctx.Err() != nil {
+			return err
+		}
+
+		if _, ok := err.(*ExitError); !ok {
+			return err
+		}
+
+		ui.Warn("%s %s failed with transient error: %s, retrying in %s (%v/%v)", command, firstArg(args), matcher.matched, backoff, attempt, policy.MaxAttempts-1)
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return err
+		}
+
+		backoff *= 2
+	}
+
+	return err
+}
+
+// firstArg returns first argument, or empty string if there are no arguments
+func firstArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+
+	return args[0]
+}
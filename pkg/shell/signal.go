@@ -0,0 +1,125 @@
+package shell
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/avinor/tau/pkg/helpers/ui"
+)
+
+var (
+	// running is the commands currently running, signals are forwarded to them
+	running = &processes{commands: map[*exec.Cmd]bool{}}
+
+	// received is the first signal received, forwarded to commands when interrupted
+	received     os.Signal
+	receivedLock sync.Mutex
+)
+
+// processes is a set of running commands
+type processes struct {
+	commands map[*exec.Cmd]bool
+	lock     sync.Mutex
+}
+
+// NotifySignals returns a context that is done when tau receives SIGINT or SIGTERM.
+// Running commands are interrupted with same signal when context is done, so they can
+// stop gracefully, and no new commands should be started. If more signals are received
+// they are forwarded directly to running commands, for instance terraform stops
+// immediately on a second interrupt. Call stop function to stop handling signals.
+func NotifySignals(parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	stopped := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				receivedLock.Lock()
+				first := received == nil
+				if first {
+					received = sig
+				}
+				receivedLock.Unlock()
+
+				if first {
+					ui.Warn("Received %s, waiting for running commands to stop", sig)
+					cancel()
+					continue
+				}
+
+				running.signal(sig)
+			case <-stopped:
+				return
+			}
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		close(stopped)
+		cancel()
+	}
+}
+
+// interruptSignal returns signal to interrupt commands with, the signal received by tau
+// or SIGINT if interrupted for other reasons, like a timeout
+func interruptSignal() os.Signal {
+	receivedLock.Lock()
+	defer receivedLock.Unlock()
+
+	if received != nil {
+		return received
+	}
+
+	return os.Interrupt
+}
+
+// signalProcess sends signal to process group of command, so child processes also
+// receive it
+func signalProcess(cmd *exec.Cmd, sig os.Signal) {
+	if cmd.Process == nil {
+		return
+	}
+
+	sysSig, ok := sig.(syscall.Signal)
+	if !ok {
+		return
+	}
+
+	if err := syscall.Kill(-cmd.Process.Pid, sysSig); err != nil {
+		ui.Debug("failed to send %s to %s: %s", sig, cmd.Path, err)
+	}
+}
+
+func (p *processes) add(cmd *exec.Cmd) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.commands[cmd] = true
+}
+
+func (p *processes) remove(cmd *exec.Cmd) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	delete(p.commands, cmd)
+}
+
+// signal sends sig to all running commands
+func (p *processes) signal(sig os.Signal) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for cmd := range p.commands {
+		signalProcess(cmd, sig)
+	}
+}
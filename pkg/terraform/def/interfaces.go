@@ -1,6 +1,8 @@
 package def
 
 import (
+	"context"
+
 	"github.com/zclconf/go-cty/cty"
 
 	"github.com/avinor/tau/pkg/config/loader"
@@ -18,11 +20,34 @@ type DependencyProcessor interface {
 // OutputProcessor can parse the output from terraform and parse it into a map of values.
 // It implements the shell.OutputProcessor interface so it can be sent into shell executor
 // and read the values directly. Calling GetOutput after executing shell command should
-// return the output values it found
+// return the output values it found, and GetSensitive the names of sensitive outputs
 type OutputProcessor interface {
 	shell.OutputProcessor
 
 	GetOutput() (map[string]cty.Value, error)
+	GetSensitive() (map[string]bool, error)
+}
+
+// PlanProcessor can parse the output from terraform show -json for a plan file. It implements
+// the shell.OutputProcessor interface so it can be sent into shell executor. Calling
+// GetPlanSummary after executing shell command returns a summary of changes in plan
+type PlanProcessor interface {
+	shell.OutputProcessor
+
+	GetPlanSummary() (*PlanSummary, error)
+}
+
+// PlanSummary is number of resources to add, change and destroy in a plan. Resources that
+// are replaced count both as added and destroyed
+type PlanSummary struct {
+	Add     int `json:"add"`
+	Change  int `json:"change"`
+	Destroy int `json:"destroy"`
+}
+
+// HasChanges returns true if plan has any changes
+func (ps *PlanSummary) HasChanges() bool {
+	return ps.Add > 0 || ps.Change > 0 || ps.Destroy > 0
 }
 
 // VersionCompatibility checks terraform executor for capabilities
@@ -40,6 +65,7 @@ type Generator interface {
 
 // Executor executes terraform commands
 type Executor interface {
-	Execute(options *shell.Options, command string, args ...string) error
+	Execute(ctx context.Context, options *shell.Options, command string, args ...string) error
 	NewOutputProcessor() OutputProcessor
+	NewPlanProcessor() PlanProcessor
 }
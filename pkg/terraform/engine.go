@@ -3,17 +3,27 @@ package terraform
 import (
 	"io/ioutil"
 	"os"
+	"sort"
 	"strings"
 
+	"github.com/go-errors/errors"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/zclconf/go-cty/cty"
 
 	"github.com/avinor/tau/pkg/config/loader"
 	"github.com/avinor/tau/pkg/helpers/ctytree"
+	"github.com/avinor/tau/pkg/helpers/paths"
 	"github.com/avinor/tau/pkg/helpers/ui"
 	"github.com/avinor/tau/pkg/terraform/def"
 	v012 "github.com/avinor/tau/pkg/terraform/v012"
 )
 
+var (
+	// inputVariablesIncorrect is returned if input variables cannot be parsed
+	inputVariablesIncorrect = errors.Errorf("could not parse input variables")
+)
+
 // Engine that can process version specific terraform commands
 type Engine struct {
 	Version string
@@ -110,6 +120,8 @@ func (e *Engine) ResolveDependencies(file *loader.ParsedFile) (bool, error) {
 		}
 	}
 
+	file.DependencyValues = values
+
 	for k, v := range ctytree.CreateTree(values).ToCtyMap() {
 		file.AddToContext(k, v)
 	}
@@ -119,13 +131,115 @@ func (e *Engine) ResolveDependencies(file *loader.ParsedFile) (bool, error) {
 
 // WriteInputVariables write the terraform.tfvars file into module folder. This file is the parsed and
 // processed variables where all dependencies and data source have been resolved and replaced with real
-// values
-func (e *Engine) WriteInputVariables(file *loader.ParsedFile) error {
+// values. If file already exists it returns the name of all variables that changed value.
+func (e *Engine) WriteInputVariables(file *loader.ParsedFile) ([]string, error) {
 	content, err := e.Generator.GenerateVariables(file)
 
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	var changed []string
+	if existing, err := ioutil.ReadFile(file.VariableFile()); err == nil {
+		changed = changedVariables(existing, content)
+	}
+
+	if err := ioutil.WriteFile(file.VariableFile(), content, os.ModePerm); err != nil {
+		return nil, err
+	}
+
+	return changed, nil
+}
+
+// InputVariables returns the input variables that would be written to terraform.tfvars, without
+// writing the file. Dependencies should be resolved first.
+func (e *Engine) InputVariables(file *loader.ParsedFile) (map[string]cty.Value, error) {
+	content, err := e.Generator.GenerateVariables(file)
+	if err != nil {
+		return nil, err
+	}
+
+	values, ok := parseVariables(content)
+	if !ok {
+		return nil, inputVariablesIncorrect
+	}
+
+	return values, nil
+}
+
+// ReadInputVariables reads the input variables from terraform.tfvars in module folder. Returns
+// nil if file has not been written.
+func (e *Engine) ReadInputVariables(file *loader.ParsedFile) (map[string]cty.Value, error) {
+	if !paths.IsFile(file.VariableFile()) {
+		return nil, nil
+	}
+
+	content, err := ioutil.ReadFile(file.VariableFile())
+	if err != nil {
+		return nil, err
+	}
+
+	values, ok := parseVariables(content)
+	if !ok {
+		return nil, inputVariablesIncorrect
+	}
+
+	return values, nil
+}
+
+// changedVariables compares two input variable files and returns the name of all variables that
+// are added, removed or have a different value. Returns nil if any of them cannot be parsed.
+func changedVariables(previous, current []byte) []string {
+	previousValues, ok := parseVariables(previous)
+	if !ok {
+		return nil
+	}
+
+	currentValues, ok := parseVariables(current)
+	if !ok {
+		return nil
+	}
+
+	changed := []string{}
+
+	for name, value := range currentValues {
+		if prev, exists := previousValues[name]; !exists || !prev.RawEquals(value) {
+			changed = append(changed, name)
+		}
+	}
+
+	for name := range previousValues {
+		if _, exists := currentValues[name]; !exists {
+			changed = append(changed, name)
+		}
+	}
+
+	sort.Strings(changed)
+
+	return changed
+}
+
+// parseVariables parses an input variables file and returns the value of all variables
+func parseVariables(content []byte) (map[string]cty.Value, bool) {
+	f, diags := hclsyntax.ParseConfig(content, "terraform.tfvars", hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, false
+	}
+
+	body, ok := f.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, false
+	}
+
+	values := map[string]cty.Value{}
+	for name, attr := range body.Attributes {
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, false
+		}
+
+		values[name] = value
 	}
 
-	return ioutil.WriteFile(file.VariableFile(), content, os.ModePerm)
+	return values, true
 }
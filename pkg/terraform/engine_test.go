@@ -0,0 +1,30 @@
+package terraform
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangedVariables(t *testing.T) {
+	tests := []struct {
+		previous string
+		current  string
+		expects  []string
+	}{
+		{`name = "test"`, `name = "test"`, []string{}},
+		{`name = "test"`, `name = "changed"`, []string{"name"}},
+		{`name = "test"`, "name = \"test\"\nsize = 3", []string{"size"}},
+		{"name = \"test\"\nsize = 3", `name = "test"`, []string{"size"}},
+		{`tags = { a = "b" }`, `tags = { a = "c" }`, []string{"tags"}},
+		{`list = [1, 2]`, `list = [1, 2]`, []string{}},
+		{`invalid =`, `name = "test"`, nil},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			assert.Equal(t, test.expects, changedVariables([]byte(test.previous), []byte(test.current)))
+		})
+	}
+}
@@ -74,11 +74,14 @@ func (d *DependencyProcessor) Process() (map[string]cty.Value, bool, error) {
 	debugLog := processors.NewUI(ui.Debug)
 	errorLog := processors.NewUI(ui.Error)
 
+	// Apply and output print values of dependency outputs, which can be secrets
 	options := &shell.Options{
 		Stdout:           shell.Processors(debugLog),
 		Stderr:           shell.Processors(d, errorLog),
 		WorkingDirectory: dest,
 		Env:              d.ParsedFile.Env,
+		Retry:            d.ParsedFile.RetryPolicy(),
+		SensitiveStdout:  true,
 	}
 
 	if d.runInSeparateEnv {
@@ -94,12 +97,12 @@ func (d *DependencyProcessor) Process() (map[string]cty.Value, bool, error) {
 	ui.Info("- Processing dependency %s", base)
 
 	ui.Debug("running terraform init on %s", base)
-	if err := d.executor.Execute(options, "init", "-input=false"); err != nil {
+	if err := d.executor.Execute(d.ParsedFile.Context(), options, "init", "-input=false"); err != nil {
 		return nil, false, err
 	}
 
 	ui.Debug("running terraform apply on %s", base)
-	if err := d.executor.Execute(options, "apply", "-auto-approve", "-input=false"); err != nil {
+	if err := d.executor.Execute(d.ParsedFile.Context(), options, "apply", "-auto-approve", "-input=false"); err != nil {
 		// If it accepts failure then just exit with no error, but create = false
 		if d.acceptApplyFailure {
 			return nil, false, nil
@@ -108,11 +111,13 @@ func (d *DependencyProcessor) Process() (map[string]cty.Value, bool, error) {
 		return nil, false, err
 	}
 
+	// Output is not retried, as output processor would read output from all attempts
 	outputProcessor := &OutputProcessor{decodeNames: true}
 	options.Stdout = shell.Processors(outputProcessor)
+	options.Retry = nil
 
 	ui.Debug("reading output from %s", base)
-	if err := d.executor.Execute(options, "output", "-json"); err != nil {
+	if err := d.executor.Execute(d.ParsedFile.Context(), options, "output", "-json"); err != nil {
 		return nil, false, err
 	}
 
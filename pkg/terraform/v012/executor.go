@@ -1,6 +1,9 @@
 package v012
 
 import (
+	"context"
+
+	"github.com/avinor/tau/pkg/helpers/ui"
 	"github.com/avinor/tau/pkg/shell"
 	"github.com/avinor/tau/pkg/terraform/def"
 )
@@ -9,13 +12,20 @@ import (
 type Executor struct{}
 
 // Execute wraps shell.Execute to execute terraform commands
-func (e *Executor) Execute(options *shell.Options, command string, args ...string) error {
+func (e *Executor) Execute(ctx context.Context, options *shell.Options, command string, args ...string) error {
+	defer ui.WithContext(ui.TerraformContext, command)()
+
 	args = append([]string{command}, args...)
 
-	return shell.Execute(options, "terraform", args...)
+	return shell.Execute(ctx, options, "terraform", args...)
 }
 
 // NewOutputProcessor returns a new output processor
 func (e *Executor) NewOutputProcessor() def.OutputProcessor {
 	return &OutputProcessor{}
 }
+
+// NewPlanProcessor returns a new plan processor
+func (e *Executor) NewPlanProcessor() def.PlanProcessor {
+	return &PlanProcessor{}
+}
@@ -19,21 +19,23 @@ type OutputProcessor struct {
 	decodeNames bool
 }
 
+// outputMeta is a single output from `terraform output -json`
+type outputMeta struct {
+	Sensitive bool            `json:"sensitive"`
+	Type      json.RawMessage `json:"type"`
+	Value     json.RawMessage `json:"value"`
+}
+
 // GetOutput takes the output from terraform command and parses the output into
 // a map of string -> cty.Value
 func (op *OutputProcessor) GetOutput() (map[string]cty.Value, error) {
-	type OutputMeta struct {
-		Sensitive bool            `json:"sensitive"`
-		Type      json.RawMessage `json:"type"`
-		Value     json.RawMessage `json:"value"`
-	}
-	outputs := map[string]OutputMeta{}
-	values := map[string]cty.Value{}
-
-	if err := json.Unmarshal([]byte(op.String()), &outputs); err != nil {
+	outputs, err := op.parse()
+	if err != nil {
 		return nil, err
 	}
 
+	values := map[string]cty.Value{}
+
 	for name, meta := range outputs {
 		ctyType, err := ctyjson.UnmarshalType(meta.Type)
 		if err != nil {
@@ -45,12 +47,47 @@ func (op *OutputProcessor) GetOutput() (map[string]cty.Value, error) {
 			return nil, err
 		}
 
-		if op.decodeNames {
-			name = decodeName(name)
-		}
-
 		values[name] = ctyValue
 	}
 
 	return values, nil
 }
+
+// GetSensitive takes the output from terraform command and returns the names of outputs
+// that are marked as sensitive
+func (op *OutputProcessor) GetSensitive() (map[string]bool, error) {
+	outputs, err := op.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	sensitive := map[string]bool{}
+
+	for name, meta := range outputs {
+		if meta.Sensitive {
+			sensitive[name] = true
+		}
+	}
+
+	return sensitive, nil
+}
+
+// parse parses the output from terraform command, decoding names if they were encoded
+func (op *OutputProcessor) parse() (map[string]outputMeta, error) {
+	parsed := map[string]outputMeta{}
+
+	if err := json.Unmarshal([]byte(op.String()), &parsed); err != nil {
+		return nil, err
+	}
+
+	if !op.decodeNames {
+		return parsed, nil
+	}
+
+	outputs := map[string]outputMeta{}
+	for name, meta := range parsed {
+		outputs[decodeName(name)] = meta
+	}
+
+	return outputs, nil
+}
@@ -0,0 +1,48 @@
+package v012
+
+import (
+	"encoding/json"
+
+	"github.com/avinor/tau/pkg/shell/processors"
+	"github.com/avinor/tau/pkg/terraform/def"
+)
+
+// PlanProcessor processes output from terraform show -json for a plan file and counts
+// the changes. Implements the def.PlanProcessor interface
+type PlanProcessor struct {
+	processors.Buffer
+}
+
+// GetPlanSummary takes the output from terraform show command and counts number of
+// resources to add, change and destroy
+func (pp *PlanProcessor) GetPlanSummary() (*def.PlanSummary, error) {
+	type Plan struct {
+		ResourceChanges []struct {
+			Change struct {
+				Actions []string `json:"actions"`
+			} `json:"change"`
+		} `json:"resource_changes"`
+	}
+
+	plan := &Plan{}
+	if err := json.Unmarshal([]byte(pp.String()), plan); err != nil {
+		return nil, err
+	}
+
+	summary := &def.PlanSummary{}
+
+	for _, resource := range plan.ResourceChanges {
+		for _, action := range resource.Change.Actions {
+			switch action {
+			case "create":
+				summary.Add++
+			case "update":
+				summary.Change++
+			case "delete":
+				summary.Destroy++
+			}
+		}
+	}
+
+	return summary, nil
+}
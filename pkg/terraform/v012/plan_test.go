@@ -0,0 +1,50 @@
+package v012
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/avinor/tau/pkg/terraform/def"
+)
+
+func TestGetPlanSummary(t *testing.T) {
+	tests := []struct {
+		output  string
+		expects *def.PlanSummary
+		err     bool
+	}{
+		{`{"format_version": "0.1"}`, &def.PlanSummary{}, false},
+		{
+			`{"resource_changes": [
+				{"change": {"actions": ["create"]}},
+				{"change": {"actions": ["update"]}},
+				{"change": {"actions": ["no-op"]}},
+				{"change": {"actions": ["read"]}},
+				{"change": {"actions": ["delete"]}},
+				{"change": {"actions": ["delete", "create"]}}
+			]}`,
+			&def.PlanSummary{Add: 2, Change: 1, Destroy: 2},
+			false,
+		},
+		{`invalid`, nil, true},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			pp := &PlanProcessor{}
+			pp.Write(test.output)
+
+			summary, err := pp.GetPlanSummary()
+
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expects, summary)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package terraform
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/terraform/configs"
+	"github.com/hashicorp/terraform/helper/didyoumean"
+	"github.com/spf13/afero"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+
+	"github.com/avinor/tau/pkg/config/loader"
+	"github.com/avinor/tau/pkg/helpers/ui"
+)
+
+// ValidateInputVariables validates the input variables written to module against the variables
+// declared in module. It returns a warning for inputs the module does not declare, and errors for
+// required variables that are not set and values that cannot be converted to the declared type.
+// Diagnostics reference the inputs in tau file, or the inputs block if variable is missing.
+//
+// If module cannot be parsed, for instance if it is not initialized, it returns no diagnostics.
+func (e *Engine) ValidateInputVariables(file *loader.ParsedFile) (hcl.Diagnostics, error) {
+	module, diags := configs.NewParser(afero.NewOsFs()).LoadConfigDir(file.ModuleDir())
+	if diags.HasErrors() {
+		ui.Debug("could not parse module to validate input variables: %s", diags)
+		return nil, nil
+	}
+
+	values, err := e.ReadInputVariables(file)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs := hcl.Attributes{}
+	inputsRange := hcl.Range{Filename: file.FullPath}
+
+	if file.Config.Inputs != nil {
+		inputsRange = file.Config.Inputs.Config.MissingItemRange()
+
+		if attrs, diags = file.Config.Inputs.Config.JustAttributes(); diags.HasErrors() {
+			return nil, diags
+		}
+	}
+
+	declared := []string{}
+	for name := range module.Variables {
+		declared = append(declared, name)
+	}
+
+	diags = hcl.Diagnostics{}
+
+	for _, name := range sortedAttributeNames(attrs) {
+		attr := attrs[name]
+		variable, ok := module.Variables[name]
+
+		if !ok {
+			detail := fmt.Sprintf("Module does not declare a variable named %q.", name)
+			if suggestion := didyoumean.NameSuggestion(name, declared); suggestion != "" {
+				detail = fmt.Sprintf("%s Did you mean %q?", detail, suggestion)
+			}
+
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagWarning,
+				Summary:  "Unknown input variable",
+				Detail:   detail,
+				Subject:  attr.NameRange.Ptr(),
+			})
+			continue
+		}
+
+		value, ok := values[name]
+		if !ok || variable.Type == cty.DynamicPseudoType {
+			continue
+		}
+
+		if _, err := convert.Convert(value, variable.Type); err != nil {
+			diags = diags.Append(&hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "Invalid value for input variable",
+				Detail:   fmt.Sprintf("Value for %q is not compatible with type %s declared in module: %s.", name, variable.Type.FriendlyName(), err),
+				Subject:  attr.Expr.Range().Ptr(),
+			})
+		}
+	}
+
+	sort.Strings(declared)
+
+	for _, name := range declared {
+		variable := module.Variables[name]
+
+		if !variable.Required() || isVariableSet(file, name, attrs, values) {
+			continue
+		}
+
+		diags = diags.Append(&hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Missing required input variable",
+			Detail:   fmt.Sprintf("Module requires variable %q, but it is not set in inputs.", name),
+			Subject:  inputsRange.Ptr(),
+		})
+	}
+
+	return diags, nil
+}
+
+// isVariableSet returns true if variable is set in inputs, input variables file, or as
+// TF_VAR_ environment variable
+func isVariableSet(file *loader.ParsedFile, name string, attrs hcl.Attributes, values map[string]cty.Value) bool {
+	if _, ok := attrs[name]; ok {
+		return true
+	}
+
+	if _, ok := values[name]; ok {
+		return true
+	}
+
+	envName := fmt.Sprintf("TF_VAR_%s", name)
+
+	if _, ok := file.Env[envName]; ok {
+		return true
+	}
+
+	_, ok := os.LookupEnv(envName)
+	return ok
+}
+
+// sortedAttributeNames returns names of attributes sorted, so diagnostics are always
+// returned in same order
+func sortedAttributeNames(attrs hcl.Attributes) []string {
+	names := []string{}
+	for name := range attrs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
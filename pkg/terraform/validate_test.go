@@ -0,0 +1,134 @@
+package terraform
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/avinor/tau/pkg/config/loader"
+)
+
+const validateModule = `
+variable "name" {
+  type = string
+}
+
+variable "size" {
+  type    = number
+  default = 1
+}
+
+variable "tags" {
+  type    = map(string)
+  default = {}
+}
+
+variable "location" {}
+`
+
+func TestValidateInputVariables(t *testing.T) {
+	tests := []struct {
+		Inputs   string
+		Env      map[string]string
+		Warnings []string
+		Errors   []string
+	}{
+		{
+			"name = \"test\"\nlocation = \"westeurope\"",
+			nil,
+			[]string{},
+			[]string{},
+		},
+		{
+			"name = \"test\"\nlocation = \"westeurope\"\nsise = 3",
+			nil,
+			[]string{`Module does not declare a variable named "sise". Did you mean "size"?`},
+			[]string{},
+		},
+		{
+			"name = \"test\"",
+			nil,
+			[]string{},
+			[]string{`Module requires variable "location", but it is not set in inputs.`},
+		},
+		{
+			"name = \"test\"",
+			map[string]string{"TF_VAR_location": "westeurope"},
+			[]string{},
+			[]string{},
+		},
+		{
+			"name = \"test\"\nlocation = \"westeurope\"\nsize = \"large\"\ntags = { a = [\"b\"] }",
+			nil,
+			[]string{},
+			[]string{
+				`Value for "size" is not compatible with type number declared in module: a number is required.`,
+				`Value for "tags" is not compatible with type map of string declared in module: element "a": string required.`,
+			},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%02d", i), func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "tau-validate")
+			assert.NoError(t, err)
+			defer os.RemoveAll(dir)
+
+			filename := filepath.Join(dir, fmt.Sprintf("validate%02d.hcl", i))
+			content := []byte(fmt.Sprintf("module {\n source = \"test\"\n}\n\ninputs {\n%s\n}\n", test.Inputs))
+
+			file, err := loader.NewParsedFile(filename, content, filepath.Join(dir, ".tau"), filepath.Join(dir, ".tau_cache"))
+			assert.NoError(t, err)
+
+			for key, value := range test.Env {
+				file.Env[key] = value
+			}
+
+			assert.NoError(t, os.MkdirAll(file.ModuleDir(), 0755))
+			assert.NoError(t, ioutil.WriteFile(filepath.Join(file.ModuleDir(), "variables.tf"), []byte(validateModule), 0644))
+			assert.NoError(t, ioutil.WriteFile(file.VariableFile(), []byte(test.Inputs), 0644))
+
+			engine := &Engine{}
+			diags, err := engine.ValidateInputVariables(file)
+			assert.NoError(t, err)
+
+			warnings := []string{}
+			errors := []string{}
+
+			for _, diag := range diags {
+				assert.Equal(t, filename, diag.Subject.Filename)
+
+				if diag.Severity == hcl.DiagError {
+					errors = append(errors, diag.Detail)
+				} else {
+					warnings = append(warnings, diag.Detail)
+				}
+			}
+
+			assert.Equal(t, test.Warnings, warnings)
+			assert.Equal(t, test.Errors, errors)
+		})
+	}
+}
+
+func TestValidateInputVariablesModuleNotInitialized(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tau-validate")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "notinit.hcl")
+	content := []byte("module {\n source = \"test\"\n}\n\ninputs {\n name = \"test\"\n}\n")
+
+	file, err := loader.NewParsedFile(filename, content, filepath.Join(dir, ".tau"), filepath.Join(dir, ".tau_cache"))
+	assert.NoError(t, err)
+
+	engine := &Engine{}
+	diags, err := engine.ValidateInputVariables(file)
+	assert.NoError(t, err)
+	assert.Empty(t, diags)
+}
@@ -1,6 +1,7 @@
 package terraform
 
 import (
+	"context"
 	"regexp"
 
 	"github.com/avinor/tau/pkg/helpers/ui"
@@ -26,7 +27,7 @@ func Version() string {
 		Stderr: shell.Processors(logp),
 	}
 
-	if err := shell.Execute(options, "terraform", "version"); err != nil {
+	if err := shell.Execute(context.Background(), options, "terraform", "version"); err != nil {
 		return ""
 	}
 